@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/joseph-beck/routey/pkg/binding"
 	routey "github.com/joseph-beck/routey/pkg/router"
 	"github.com/joseph-beck/routey/pkg/status"
 )
@@ -21,7 +22,7 @@ func ping() routey.HandlerFunc {
 
 	f := func(c *routey.Context) {
 		var t Thing
-		c.ShouldBindJSON(&t)
+		c.ShouldBindBodyWith(&t, binding.JSON)
 		fmt.Println(t)
 	}
 
@@ -29,7 +30,7 @@ func ping() routey.HandlerFunc {
 		f(c)
 
 		var t Thing
-		c.ShouldBindJSON(&t)
+		c.ShouldBindBodyWith(&t, binding.JSON)
 		fmt.Println(t)
 		p := c.Protocol()
 
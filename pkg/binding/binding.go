@@ -1,7 +1,10 @@
 package binding
 
 import (
+	"errors"
+	"mime"
 	"net/http"
+	"sync"
 )
 
 type Binder interface {
@@ -9,9 +12,103 @@ type Binder interface {
 	Bind(*http.Request, any) error
 }
 
+// Binds an already read body, used when the body must be read more than once
+type BindingBody interface {
+	Binder
+	BindBody([]byte, any) error
+}
+
+// A Binder that parses multipart forms, such as Form, given the memory to use
+type MultipartBinder interface {
+	Binder
+	WithMaxMemory(int64) Binder
+}
+
+// Binds the path params of a request, these are not held on the http.Request
+type URIBinder interface {
+	Name() string
+	BindURI(map[string][]string, any) error
+}
+
+var (
+	JSON     = jsonBinding{}
+	TOML     = tomlBinding{}
+	XML      = xmlBinding{}
+	YAML     = yamlBinding{}
+	MsgPack  = msgpackBinding{}
+	CBOR     = cborBinding{}
+	ProtoBuf = protobufBinding{}
+	Query    = queryBinding{}
+	Form     = formBinding{}
+	URI      = uriBinding{}
+	Header   = headerBinding{}
+)
+
+// The memory used when parsing a multipart form, anything larger is stored in temporary files
+var MultipartMemory int64 = 32 << 20
+
+// Returned when there is no Binder registered for a Content-Type
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
 var (
-	JSON = jsonBinding{}
-	TOML = tomlBinding{}
-	XML  = xmlBinding{}
-	YAML = yamlBinding{}
+	mu      sync.RWMutex
+	binders = map[string]Binder{
+		"application/json":                  JSON,
+		"application/xml":                   XML,
+		"text/xml":                          XML,
+		"application/x-yaml":                YAML,
+		"application/yaml":                  YAML,
+		"text/yaml":                         YAML,
+		"application/toml":                  TOML,
+		"application/msgpack":               MsgPack,
+		"application/x-msgpack":             MsgPack,
+		"application/vnd.msgpack":           MsgPack,
+		"application/cbor":                  CBOR,
+		"application/x-protobuf":            ProtoBuf,
+		"application/protobuf":              ProtoBuf,
+		"application/x-www-form-urlencoded": Form,
+		"multipart/form-data":               Form,
+	}
 )
+
+// Register a Binder for a MIME type, replacing any Binder already registered
+func Register(m string, b Binder) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	binders[m] = b
+}
+
+// Get the Binder registered for a MIME type
+func Lookup(m string) (Binder, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	b, ok := binders[m]
+	return b, ok
+}
+
+// Get the Binder for a request with the given method and Content-Type.
+//
+// GET and HEAD requests without a Content-Type are bound from the query,
+// other requests without a Content-Type are treated as JSON.
+func Default(method string, ct string) (Binder, error) {
+	if ct == "" {
+		if method == http.MethodGet || method == http.MethodHead {
+			return Query, nil
+		}
+		return JSON, nil
+	}
+
+	m, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, errors.Join(ErrUnsupportedMediaType, err)
+	}
+
+	b, ok := Lookup(m)
+	if !ok {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	return b, nil
+}
@@ -0,0 +1,67 @@
+package binding
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type binderMock struct{}
+
+func (binderMock) Name() string {
+	return "mock"
+}
+
+func (binderMock) Bind(*http.Request, any) error {
+	return nil
+}
+
+func TestDefault(t *testing.T) {
+	b, err := Default(http.MethodPost, "application/json; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, "json", b.Name())
+
+	b, err = Default(http.MethodGet, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "query", b.Name())
+
+	b, err = Default(http.MethodPost, "multipart/form-data; boundary=x")
+	assert.NoError(t, err)
+	assert.Equal(t, "form", b.Name())
+
+	_, err = Default(http.MethodPost, "application/unknown")
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+}
+
+func TestRegister(t *testing.T) {
+	Register("application/mock", binderMock{})
+
+	b, ok := Lookup("application/mock")
+	assert.True(t, ok)
+	assert.Equal(t, "mock", b.Name())
+
+	b, err := Default(http.MethodPut, "application/mock")
+	assert.NoError(t, err)
+	assert.Equal(t, "mock", b.Name())
+}
+
+func TestBindingBody(t *testing.T) {
+	type body struct {
+		Name string `json:"name" xml:"name" yaml:"name" toml:"name"`
+	}
+
+	cases := map[BindingBody][]byte{
+		JSON: []byte(`{"name":"routey"}`),
+		XML:  []byte(`<body><name>routey</name></body>`),
+		YAML: []byte(`name: routey`),
+		TOML: []byte(`name = "routey"`),
+	}
+
+	for bb, c := range cases {
+		var b body
+		err := bb.BindBody(c, &b)
+		assert.NoError(t, err, bb.Name())
+		assert.Equal(t, "routey", b.Name, bb.Name())
+	}
+}
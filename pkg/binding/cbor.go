@@ -0,0 +1,39 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+type cborBinding struct{}
+
+func (cborBinding) Name() string {
+	return "cbor"
+}
+
+func (c cborBinding) Bind(r *http.Request, a any) error {
+	if r.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	return c.decodeCBOR(r.Body, a)
+}
+
+func (c cborBinding) BindBody(b []byte, a any) error {
+	return c.decodeCBOR(bytes.NewReader(b), a)
+}
+
+func (c cborBinding) decodeCBOR(r io.Reader, a any) error {
+	decoder := cbor.NewDecoder(r)
+
+	err := decoder.Decode(a)
+	if err != nil {
+		return err
+	}
+
+	return validate(a)
+}
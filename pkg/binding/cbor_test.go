@@ -0,0 +1,25 @@
+package binding
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCBORBind(t *testing.T) {
+	type body struct {
+		Name string `cbor:"name" binding:"required"`
+	}
+
+	b, err := cbor.Marshal(body{Name: "routey"})
+	assert.NoError(t, err)
+
+	var c body
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(b))
+	err = CBOR.Bind(r, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", c.Name)
+}
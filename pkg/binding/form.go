@@ -0,0 +1,64 @@
+package binding
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// Binds url encoded and multipart forms
+//
+//   - MaxMemory: memory used when parsing a multipart form, defaults to MultipartMemory
+type formBinding struct {
+	MaxMemory int64
+}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+func (f formBinding) Bind(r *http.Request, a any) error {
+	multipart, err := f.parse(r)
+	if err != nil {
+		return err
+	}
+
+	err = MapValues(a, r.Form, "form")
+	if err != nil {
+		return err
+	}
+
+	if multipart {
+		err = mapFiles(a, r.MultipartForm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return validate(a)
+}
+
+// Get the form Binder using n bytes of memory when parsing a multipart form
+func (f formBinding) WithMaxMemory(n int64) Binder {
+	f.MaxMemory = n
+	return f
+}
+
+func (f formBinding) parse(r *http.Request) (bool, error) {
+	m, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if m != "multipart/form-data" {
+		return false, r.ParseForm()
+	}
+
+	n := f.MaxMemory
+	if n <= 0 {
+		n = MultipartMemory
+	}
+
+	err := r.ParseMultipartForm(n)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return false, err
+	}
+
+	return r.MultipartForm != nil, nil
+}
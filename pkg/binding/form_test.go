@@ -0,0 +1,64 @@
+package binding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type formMock struct {
+	Name string                `form:"name"`
+	Age  int                   `form:"age"`
+	File *multipart.FileHeader `form:"file"`
+}
+
+func TestFormBind(t *testing.T) {
+	var f formMock
+	r := httptest.NewRequest("POST", "/", strings.NewReader("name=routey&age=3"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err := Form.Bind(r, &f)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", f.Name)
+	assert.Equal(t, 3, f.Age)
+}
+
+func TestFormBindMultipart(t *testing.T) {
+	b := &bytes.Buffer{}
+	m := multipart.NewWriter(b)
+	m.WriteField("name", "routey")
+	w, _ := m.CreateFormFile("file", "routey.txt")
+	w.Write([]byte("hello"))
+	m.Close()
+
+	var f formMock
+	r := httptest.NewRequest("POST", "/", b)
+	r.Header.Set("Content-Type", m.FormDataContentType())
+	err := Form.Bind(r, &f)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", f.Name)
+	assert.Equal(t, "routey.txt", f.File.Filename)
+}
+
+func TestFormMaxMemory(t *testing.T) {
+	b := &bytes.Buffer{}
+	m := multipart.NewWriter(b)
+	w, _ := m.CreateFormFile("file", "routey.txt")
+	w.Write([]byte("hello routey"))
+	m.Close()
+
+	var f formMock
+	r := httptest.NewRequest("POST", "/", b)
+	r.Header.Set("Content-Type", m.FormDataContentType())
+	err := Form.WithMaxMemory(4).Bind(r, &f)
+	assert.NoError(t, err)
+
+	o, err := f.File.Open()
+	assert.NoError(t, err)
+	defer o.Close()
+	assert.IsType(t, &os.File{}, o)
+}
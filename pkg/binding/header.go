@@ -0,0 +1,20 @@
+package binding
+
+import (
+	"net/http"
+)
+
+type headerBinding struct{}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+func (h headerBinding) Bind(r *http.Request, a any) error {
+	err := MapHeader(a, r.Header)
+	if err != nil {
+		return err
+	}
+
+	return validate(a)
+}
@@ -0,0 +1,24 @@
+package binding
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerMock struct {
+	Token   string `header:"x-token"`
+	Request int    `header:"X-Request-Id"`
+}
+
+func TestHeaderBind(t *testing.T) {
+	var h headerMock
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Token", "abc")
+	r.Header.Set("X-Request-Id", "12")
+	err := Header.Bind(r, &h)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", h.Token)
+	assert.Equal(t, 12, h.Request)
+}
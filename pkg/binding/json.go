@@ -15,12 +15,11 @@ func (j jsonBinding) Name() string {
 }
 
 func (j jsonBinding) Bind(r *http.Request, a any) error {
-	buff, err := j.readBody(r)
-	if err != nil {
-		return err
+	if r.Body == nil {
+		return errors.New("invalid request")
 	}
 
-	return j.decodeJSON(buff, a)
+	return j.decodeJSON(r.Body, a)
 }
 
 func (j jsonBinding) BindBody(b []byte, a any) error {
@@ -36,19 +35,3 @@ func (j jsonBinding) decodeJSON(r io.Reader, a any) error {
 	}
 	return validate(a)
 }
-
-func (j jsonBinding) readBody(r *http.Request) (*bytes.Buffer, error) {
-	if r.Body == nil {
-		return nil, errors.New("invalid request")
-	}
-
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	buff := bytes.NewBuffer(b)
-	r.Body = io.NopCloser(buff)
-	n := bytes.NewBuffer(b)
-	return n, nil
-}
@@ -0,0 +1,297 @@
+package binding
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Looks up the values of a key, returning false if there are none
+type lookupFunc func(k string) ([]string, bool)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	fileHeaderType      = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// Maps the values onto the fields of a, using the given struct tag to find each key.
+//
+//   - a: a pointer to a struct
+//
+//   - v: the values to map, for example a url.Values
+//
+//   - t: the struct tag to read the key from, for example query
+//
+// A tag can give a default with `query:"page,default=1"`, time.Time fields can be
+// given a layout with `time_format:"2006-01-02"` or `time_format:"unix"`.
+func MapValues(a any, v map[string][]string, t string) error {
+	return mapWith(a, t, func(k string) ([]string, bool) {
+		vs, ok := v[k]
+		return vs, ok && len(vs) > 0
+	})
+}
+
+// Maps the header onto the fields of a using the header tag, keys are canonicalized.
+func MapHeader(a any, h http.Header) error {
+	return mapWith(a, "header", func(k string) ([]string, bool) {
+		vs, ok := h[textproto.CanonicalMIMEHeaderKey(k)]
+		return vs, ok && len(vs) > 0
+	})
+}
+
+// Map onto a using the tag and the lookup
+func mapWith(a any, t string, l lookupFunc) error {
+	p := reflect.ValueOf(a)
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return errors.New("binding requires a non nil pointer")
+	}
+
+	e := p.Elem()
+	if e.Kind() != reflect.Struct {
+		return errors.New("binding requires a pointer to a struct")
+	}
+
+	return mapStruct(e, t, l)
+}
+
+// Map the values onto each of the fields of the struct
+func mapStruct(s reflect.Value, t string, l lookupFunc) error {
+	st := s.Type()
+
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag, ok := f.Tag.Lookup(t)
+		if tag == "-" {
+			continue
+		}
+
+		fv := s.Field(i)
+		if !ok {
+			if f.Type.Kind() == reflect.Struct && f.Type != timeType {
+				err := mapStruct(fv, t, l)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		k, d, hasDefault := parseTag(tag)
+		if k == "" {
+			k = f.Name
+		}
+
+		vs, ok := l(k)
+		if !ok {
+			if !hasDefault {
+				continue
+			}
+			vs = []string{d}
+		}
+
+		err := setField(fv, f, vs)
+		if err != nil {
+			return fmt.Errorf("unable to bind %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+// Parse a tag of the form name,default=value
+func parseTag(t string) (string, string, bool) {
+	n, o, _ := strings.Cut(t, ",")
+
+	for o != "" {
+		var e string
+		e, o, _ = strings.Cut(o, ",")
+
+		d, ok := strings.CutPrefix(e, "default=")
+		if ok {
+			return n, d, true
+		}
+	}
+
+	return n, "", false
+}
+
+// Set the field using the given values
+func setField(v reflect.Value, f reflect.StructField, vs []string) error {
+	if v.Kind() == reflect.Slice && !implementsText(v) {
+		s := reflect.MakeSlice(v.Type(), len(vs), len(vs))
+		for i, e := range vs {
+			err := setValue(s.Index(i), f, e)
+			if err != nil {
+				return err
+			}
+		}
+
+		v.Set(s)
+		return nil
+	}
+
+	if v.Kind() == reflect.Array {
+		if len(vs) != v.Len() {
+			return fmt.Errorf("expected %d values but got %d", v.Len(), len(vs))
+		}
+		for i, e := range vs {
+			err := setValue(v.Index(i), f, e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return setValue(v, f, vs[0])
+}
+
+// Set a single value, parsing it into the type of the field
+func setValue(v reflect.Value, f reflect.StructField, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), f, s)
+	}
+
+	switch v.Type() {
+	case timeType:
+		return setTime(v, f, s)
+	case durationType:
+		if s == "" {
+			s = "0"
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	if implementsText(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			s = "false"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			s = "0"
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			s = "0"
+		}
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// Set a time using the time_format of the field
+func setTime(v reflect.Value, f reflect.StructField, s string) error {
+	if s == "" {
+		v.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+
+	l := f.Tag.Get("time_format")
+	switch l {
+	case "":
+		l = time.RFC3339
+	case "unix":
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.Unix(i, 0)))
+		return nil
+	}
+
+	t, err := time.Parse(l, s)
+	if err != nil {
+		return err
+	}
+
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// Does the value implement encoding.TextUnmarshaler?
+func implementsText(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType)
+}
+
+// Map the files of a multipart form onto the fields of a, using the form tag
+func mapFiles(a any, m *multipart.Form) error {
+	e := reflect.ValueOf(a).Elem()
+	st := e.Type()
+
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		k, _, _ := parseTag(f.Tag.Get("form"))
+		if k == "" || k == "-" || !f.IsExported() {
+			continue
+		}
+
+		fs := m.File[k]
+		if len(fs) == 0 {
+			continue
+		}
+
+		switch {
+		case f.Type == fileHeaderType:
+			e.Field(i).Set(reflect.ValueOf(fs[0]))
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem() == fileHeaderType:
+			e.Field(i).Set(reflect.ValueOf(fs))
+		}
+	}
+
+	return nil
+}
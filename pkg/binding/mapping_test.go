@@ -0,0 +1,90 @@
+package binding
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mappingMock struct {
+	Name   string   `query:"name"`
+	Page   int      `query:"page"`
+	Ratio  float64  `query:"ratio"`
+	Active bool     `query:"active"`
+	Tags   []string `query:"tag"`
+	Skip   string   `query:"-"`
+}
+
+func TestMapValues(t *testing.T) {
+	var m mappingMock
+	err := MapValues(&m, map[string][]string{
+		"name":   {"routey"},
+		"page":   {"2"},
+		"ratio":  {"0.5"},
+		"active": {"true"},
+		"tag":    {"a", "b"},
+		"-":      {"skipped"},
+	}, "query")
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", m.Name)
+	assert.Equal(t, 2, m.Page)
+	assert.Equal(t, 0.5, m.Ratio)
+	assert.True(t, m.Active)
+	assert.Equal(t, []string{"a", "b"}, m.Tags)
+	assert.Equal(t, "", m.Skip)
+}
+
+func TestMapValuesInvalid(t *testing.T) {
+	var m mappingMock
+	err := MapValues(&m, map[string][]string{"page": {"two"}}, "query")
+	assert.Error(t, err)
+
+	err = MapValues(m, map[string][]string{}, "query")
+	assert.Error(t, err)
+}
+
+type mappingLevel int
+
+func (l *mappingLevel) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+
+	return nil
+}
+
+type mappingTypes struct {
+	Since   time.Time     `query:"since" time_format:"2006-01-02"`
+	Unix    time.Time     `query:"unix" time_format:"unix"`
+	Timeout time.Duration `query:"timeout,default=5s"`
+	Level   mappingLevel  `query:"level"`
+	Limit   *int          `query:"limit"`
+	Missing *int          `query:"missing"`
+}
+
+func TestMapValuesTypes(t *testing.T) {
+	var m mappingTypes
+	err := MapValues(&m, map[string][]string{
+		"since": {"2024-02-01"},
+		"unix":  {"60"},
+		"level": {"high"},
+		"limit": {"10"},
+	}, "query")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), m.Since)
+	assert.Equal(t, int64(60), m.Unix.Unix())
+	assert.Equal(t, 5*time.Second, m.Timeout)
+	assert.Equal(t, mappingLevel(2), m.Level)
+	assert.Equal(t, 10, *m.Limit)
+	assert.Nil(t, m.Missing)
+
+	err = MapValues(&m, map[string][]string{"level": {"none"}}, "query")
+	assert.Error(t, err)
+}
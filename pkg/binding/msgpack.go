@@ -0,0 +1,39 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackBinding struct{}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+func (m msgpackBinding) Bind(r *http.Request, a any) error {
+	if r.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	return m.decodeMsgPack(r.Body, a)
+}
+
+func (m msgpackBinding) BindBody(b []byte, a any) error {
+	return m.decodeMsgPack(bytes.NewReader(b), a)
+}
+
+func (m msgpackBinding) decodeMsgPack(r io.Reader, a any) error {
+	decoder := msgpack.NewDecoder(r)
+
+	err := decoder.Decode(a)
+	if err != nil {
+		return err
+	}
+
+	return validate(a)
+}
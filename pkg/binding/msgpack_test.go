@@ -0,0 +1,25 @@
+package binding
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgPackBind(t *testing.T) {
+	type body struct {
+		Name string `msgpack:"name" binding:"required"`
+	}
+
+	b, err := msgpack.Marshal(body{Name: "routey"})
+	assert.NoError(t, err)
+
+	var m body
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(b))
+	err = MsgPack.Bind(r, &m)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", m.Name)
+}
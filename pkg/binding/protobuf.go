@@ -0,0 +1,42 @@
+package binding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type protobufBinding struct{}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+func (p protobufBinding) Bind(r *http.Request, a any) error {
+	if r.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return p.BindBody(b, a)
+}
+
+func (p protobufBinding) BindBody(b []byte, a any) error {
+	m, ok := a.(proto.Message)
+	if !ok {
+		return errors.New("protobuf binding requires a proto.Message")
+	}
+
+	err := proto.Unmarshal(b, m)
+	if err != nil {
+		return err
+	}
+
+	return validate(a)
+}
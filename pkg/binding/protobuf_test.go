@@ -0,0 +1,26 @@
+package binding
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoBufBind(t *testing.T) {
+	b, err := proto.Marshal(wrapperspb.String("routey"))
+	assert.NoError(t, err)
+
+	m := &wrapperspb.StringValue{}
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(b))
+	err = ProtoBuf.Bind(r, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", m.GetValue())
+
+	var s struct{}
+	err = ProtoBuf.BindBody(b, &s)
+	assert.Error(t, err)
+}
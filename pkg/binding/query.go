@@ -0,0 +1,20 @@
+package binding
+
+import (
+	"net/http"
+)
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+func (q queryBinding) Bind(r *http.Request, a any) error {
+	err := MapValues(a, r.URL.Query(), "query")
+	if err != nil {
+		return err
+	}
+
+	return validate(a)
+}
@@ -0,0 +1,33 @@
+package binding
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type queryMock struct {
+	Page  int      `query:"page,default=1"`
+	Size  *int     `query:"size"`
+	Sort  string   `query:"sort" binding:"required"`
+	Field []string `query:"field"`
+}
+
+func TestQueryBind(t *testing.T) {
+	var q queryMock
+	r := httptest.NewRequest("GET", "/?sort=name&size=10&field=a&field=b", nil)
+	err := Query.Bind(r, &q)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, q.Page)
+	assert.Equal(t, 10, *q.Size)
+	assert.Equal(t, "name", q.Sort)
+	assert.Equal(t, []string{"a", "b"}, q.Field)
+}
+
+func TestQueryBindInvalid(t *testing.T) {
+	var q queryMock
+	r := httptest.NewRequest("GET", "/?page=2", nil)
+	err := Query.Bind(r, &q)
+	assert.Error(t, err)
+}
@@ -16,12 +16,11 @@ func (t tomlBinding) Name() string {
 }
 
 func (t tomlBinding) Bind(r *http.Request, a any) error {
-	buff, err := t.readBody(r)
-	if err != nil {
-		return err
+	if r.Body == nil {
+		return errors.New("invalid request")
 	}
 
-	return t.decodeToml(buff, a)
+	return t.decodeToml(r.Body, a)
 }
 
 func (t tomlBinding) BindBody(b []byte, a any) error {
@@ -36,21 +35,5 @@ func (t tomlBinding) decodeToml(r io.Reader, a any) error {
 		return err
 	}
 
-	return decoder.Decode(a)
-}
-
-func (t tomlBinding) readBody(r *http.Request) (*bytes.Buffer, error) {
-	if r.Body == nil {
-		return nil, errors.New("invalid request")
-	}
-
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	buff := bytes.NewBuffer(b)
-	r.Body = io.NopCloser(buff)
-	n := bytes.NewBuffer(b)
-	return n, nil
+	return validate(a)
 }
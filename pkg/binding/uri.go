@@ -0,0 +1,16 @@
+package binding
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+func (u uriBinding) BindURI(m map[string][]string, a any) error {
+	err := MapValues(a, m, "uri")
+	if err != nil {
+		return err
+	}
+
+	return validate(a)
+}
@@ -0,0 +1,24 @@
+package binding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uriMock struct {
+	ID   int    `uri:"id" binding:"required"`
+	Name string `uri:"name"`
+}
+
+func TestURIBind(t *testing.T) {
+	var u uriMock
+	err := URI.BindURI(map[string][]string{"id": {"4"}, "name": {"routey"}}, &u)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, u.ID)
+	assert.Equal(t, "routey", u.Name)
+
+	u = uriMock{}
+	err = URI.BindURI(map[string][]string{}, &u)
+	assert.Error(t, err)
+}
@@ -15,17 +15,17 @@ func (x xmlBinding) Name() string {
 }
 
 func (x xmlBinding) Bind(r *http.Request, a any) error {
-	buff, err := x.readBody(r)
-	if err != nil {
-		return err
+	if r.Body == nil {
+		return errors.New("invalid request")
 	}
 
-	return x.decodeXML(buff, a)
+	return x.decodeXML(r.Body, a)
 }
 
 func (x xmlBinding) BindBody(b []byte, a any) error {
 	return x.decodeXML(bytes.NewReader(b), a)
 }
+
 func (x xmlBinding) decodeXML(r io.Reader, a any) error {
 	decoder := xml.NewDecoder(r)
 
@@ -36,19 +36,3 @@ func (x xmlBinding) decodeXML(r io.Reader, a any) error {
 
 	return validate(a)
 }
-
-func (x xmlBinding) readBody(r *http.Request) (*bytes.Buffer, error) {
-	if r.Body == nil {
-		return nil, errors.New("invalid request")
-	}
-
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	buff := bytes.NewBuffer(b)
-	r.Body = io.NopCloser(buff)
-	n := bytes.NewBuffer(b)
-	return n, nil
-}
@@ -16,12 +16,11 @@ func (yamlBinding) Name() string {
 }
 
 func (y yamlBinding) Bind(r *http.Request, a any) error {
-	buff, err := y.readBody(r)
-	if err != nil {
-		return err
+	if r.Body == nil {
+		return errors.New("invalid request")
 	}
 
-	return y.decodeYAML(buff, a)
+	return y.decodeYAML(r.Body, a)
 }
 
 func (y yamlBinding) BindBody(b []byte, a any) error {
@@ -38,19 +37,3 @@ func (y yamlBinding) decodeYAML(r io.Reader, a any) error {
 
 	return validate(a)
 }
-
-func (y yamlBinding) readBody(r *http.Request) (*bytes.Buffer, error) {
-	if r.Body == nil {
-		return nil, errors.New("invalid request")
-	}
-
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	buff := bytes.NewBuffer(b)
-	r.Body = io.NopCloser(buff)
-	n := bytes.NewBuffer(b)
-	return n, nil
-}
@@ -3,12 +3,18 @@ package router
 import (
 	"fmt"
 	"html/template"
+	"io/fs"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/joseph-beck/routey/pkg/binding"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +22,8 @@ import (
 //
 //   - routes: array containg all Route structs
 //
+//   - decorators: decorate every route, wrapping the middleware and the handler
+//
 //   - port: string port
 //
 //   - logger: structured logging
@@ -24,22 +32,66 @@ import (
 //
 //   - corsMode: if localhost, 127.0.0.1 or no origin do not allow this request.
 //
+//   - reload: watch templates, parsing them again when they change, in debug mode or when Reload is set.
+//
+//   - reloadInterval: how often templates are checked for changes.
+//
+//   - maxBodySize: the largest request body allowed, 0 is unlimited.
+//
+//   - multipartMemory: memory used when parsing multipart forms, larger files are stored in temporary files.
+//
+//   - errorHandler: handles errors that abort a request, responding to the user.
+//
+//   - cookieKeys: keys used to sign and encrypt cookies, the first is used for new cookies.
+//
+//   - cookieMaxAge: how long signed and encrypted cookies are accepted for, 0 is unlimited.
+//
+//   - authorizer: decides whether a request can use a route with Roles or Policies.
+//
+//   - trustedProxies: proxies whose X-Forwarded headers are used.
+//
+//   - websockets: open WebSocket connections, closed when the App shuts down.
+//
+//   - statics: static file servers, used to fingerprint assets.
+//
 //   - htmlDelims: HTML Delimiters, these can be customized.
 //
 //   - htmlRender: HTML Renderer, an interface that renders the HTML to the user.
 //
+//   - htmlMode: whether HTML is buffered or streamed.
+//
 //   - funcMap: templates.FuncMap
 type App struct {
 	routes     []Route
 	middleware []MiddlewareFunc
+	decorators []DecoratorFunc
 	port       string
 
 	logger    *logrus.Logger
 	debugMode bool
 	corsMode  bool
 
+	reload         bool
+	reloadInterval time.Duration
+
+	maxBodySize     int64
+	multipartMemory int64
+
+	errorHandler ErrorHandlerFunc
+	cookieKeys   [][]byte
+	cookieMaxAge time.Duration
+	authorizer   Authorizer
+
+	trustedProxies []netip.Prefix
+
+	websockets map[*WSConn]struct{}
+	wsMu       sync.Mutex
+
+	statics []*staticServer
+
 	htmlDelims HTMLDelims
 	htmlRender HTMLRenderer
+	htmlMode   HTMLMode
 	funcMap    template.FuncMap
 }
 
@@ -50,10 +102,22 @@ type App struct {
 //   - Debug: do you want routey to run in debug mode?
 //
 //   - CORS: do you want to run this in local only mode?
+//
+//   - MaxBodySize: the largest request body in bytes, 0 is unlimited.
+//
+//   - MultipartMemory: memory in bytes used when parsing multipart forms, defaults to 32 MiB.
+//
+//   - Reload: do you want templates to be watched and parsed again when they change outside of debug mode? They always are in debug mode.
+//
+//   - ReloadInterval: how often templates are checked for changes when reloading, defaults to 500ms.
 type Config struct {
-	Port  string
-	Debug bool
-	CORS  bool
+	Port            string
+	Debug           bool
+	CORS            bool
+	MaxBodySize     int64
+	MultipartMemory int64
+	Reload          bool
+	ReloadInterval  time.Duration
 }
 
 // Create a new default App
@@ -65,6 +129,14 @@ func New(c ...Config) *App {
 		debugMode: true,
 		corsMode:  false,
 
+		reload:         true,
+		reloadInterval: 500 * time.Millisecond,
+
+		multipartMemory: binding.MultipartMemory,
+
+		errorHandler: defaultErrorHandler,
+		cookieMaxAge: DefaultCookieMaxAge,
+
 		htmlDelims: HTMLDelims{Left: "{{", Right: "}}"},
 		funcMap:    template.FuncMap{},
 	}
@@ -73,8 +145,22 @@ func New(c ...Config) *App {
 		a.port = c[0].Port
 		a.debugMode = c[0].Debug
 		a.corsMode = c[0].CORS
+		a.maxBodySize = c[0].MaxBodySize
+
+		if c[0].MultipartMemory > 0 {
+			a.multipartMemory = c[0].MultipartMemory
+		}
+
+		a.reload = a.debugMode || c[0].Reload
+		if c[0].ReloadInterval > 0 {
+			a.reloadInterval = c[0].ReloadInterval
+		}
 	}
 
+	a.funcMap["asset"] = a.Asset
+	a.funcMap["flush"] = htmlFlush
+	a.funcMap["csrfField"] = csrfField
+
 	return &a
 }
 
@@ -90,6 +176,21 @@ func (a *App) Use(m ...MiddlewareFunc) {
 	a.middleware = append(a.middleware, m...)
 }
 
+// Decorate every route, the first decorator is the outermost.
+// Decorators wrap the middleware and the route's own DecoratorFunc.
+func (a *App) Decorate(d ...DecoratorFunc) {
+	a.decorators = append(a.decorators, d...)
+}
+
+// Set the ErrorHandlerFunc used when a request is aborted with an error
+func (a *App) SetErrorHandler(f ErrorHandlerFunc) {
+	if f == nil {
+		f = defaultErrorHandler
+	}
+
+	a.errorHandler = f
+}
+
 // Adds a Route to the App
 func (a *App) Route(r Route) {
 	err := r.Format()
@@ -190,39 +291,79 @@ func (a *App) Options(path string, params string, handler HandlerFunc) {
 }
 
 // Load a folder of HTML files.
+// In debug mode, or with Config.Reload, the files are watched and parsed again when they change.
 func (a *App) LoadHTMLGlob(p string) {
-	t := template.Must(template.New("").Delims(a.htmlDelims.Left, a.htmlDelims.Right).Funcs(a.funcMap).ParseGlob(p))
+	parse := func() (*template.Template, error) {
+		return template.New("").Delims(a.htmlDelims.Left, a.htmlDelims.Right).Funcs(a.funcMap).ParseGlob(p)
+	}
 
-	if a.debugMode {
-		a.htmlRender = HTMLDebug{
-			Glob:    p,
-			FuncMap: a.funcMap,
-			Delims:  a.htmlDelims,
-		}
+	if a.reload {
+		a.reloadHTML(htmlTemplate(parse), osTemplates(func() ([]string, error) {
+			return filepath.Glob(p)
+		}))
 		return
 	}
 
-	a.SetHTMLTemplate(t)
+	a.SetHTMLTemplate(template.Must(parse()))
 }
 
 // Load a series of HTML files.
+// In debug mode, or with Config.Reload, the files are watched and parsed again when they change.
 func (a *App) LoadHTMLFiles(f ...string) {
-	if a.debugMode {
-		a.htmlRender = HTMLDebug{
-			Files:   f,
-			FuncMap: a.funcMap,
-			Delims:  a.htmlDelims,
-		}
+	parse := func() (*template.Template, error) {
+		return template.New("").Delims(a.htmlDelims.Left, a.htmlDelims.Right).Funcs(a.funcMap).ParseFiles(f...)
+	}
+
+	if a.reload {
+		a.reloadHTML(htmlTemplate(parse), osTemplates(func() ([]string, error) {
+			return f, nil
+		}))
 		return
 	}
 
-	t := template.Must(template.New("").Delims(a.htmlDelims.Left, a.htmlDelims.Right).Funcs(a.funcMap).ParseFiles(f...))
-	a.SetHTMLTemplate(t)
+	a.SetHTMLTemplate(template.Must(parse()))
+}
+
+// Load the HTML files of a fs.FS that match the patterns, such as an embed.FS.
+// In debug mode, or with Config.Reload, the files are watched and parsed again when they change.
+func (a *App) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	parse := func() (*template.Template, error) {
+		return template.New("").Delims(a.htmlDelims.Left, a.htmlDelims.Right).Funcs(a.funcMap).ParseFS(fsys, patterns...)
+	}
+
+	if a.reload {
+		a.reloadHTML(htmlTemplate(parse), fsTemplates(fsys, patterns...))
+		return
+	}
+
+	a.SetHTMLTemplate(template.Must(parse()))
+}
+
+// Load the HTML files embedded in the binary, in debug mode, or with Config.Reload, they are read from the directory instead,
+// so they can be edited without rebuilding. The directory should hold the same files as the fs.FS.
+func (a *App) LoadHTMLEmbed(fsys fs.FS, dir string, patterns ...string) {
+	if a.reload {
+		fsys = os.DirFS(dir)
+	}
+
+	a.LoadHTMLFS(fsys, patterns...)
 }
 
 // Set the current HTML renderer.
 func (a *App) SetHTMLTemplate(t *template.Template) {
-	a.htmlRender = HTMLRender{Template: t.Funcs(a.funcMap)}
+	a.setHTMLRender(HTMLRender{Template: t.Funcs(a.funcMap)})
+}
+
+// Load an HTMLRender from the parsed templates
+func htmlTemplate(parse func() (*template.Template, error)) func() (HTMLRenderer, error) {
+	return func() (HTMLRenderer, error) {
+		t, err := parse()
+		if err != nil {
+			return nil, err
+		}
+
+		return HTMLRender{Template: t}, nil
+	}
 }
 
 // ServerHTTP with ResponseWriter and Request
@@ -265,18 +406,25 @@ func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		if len(a.middleware) > 0 {
+		if !c.limitBody() {
+			logRequest(a.logger, e, c.status)
+			return
+		}
+
+		h := func(c *Context) {
 			for _, f := range a.middleware {
 				f(c)
 			}
-		}
 
-		if e.DecoratorFunc == nil {
-			e.HandlerFunc.Serve(c)
-			logRequest(a.logger, e, c.status)
-			return
+			f := a.authorized(&e)
+			if e.DecoratorFunc == nil {
+				f.Serve(c)
+				return
+			}
+			e.DecoratorFunc(f).Serve(c)
 		}
-		e.DecoratorFunc(e.HandlerFunc).Serve(c)
+
+		Chain(a.decorators...)(h).Serve(c)
 		logRequest(a.logger, e, c.status)
 		return
 	}
@@ -301,6 +449,7 @@ func (a *App) Run() {
 	for _, re := range a.routes {
 		logRoute(a.logger, re)
 	}
+	a.reportUnprotected()
 
 	a.logger.WithFields(logrus.Fields{
 		"STATE": "Loading",
@@ -333,6 +482,8 @@ func (a *App) Shutdown(f ...ShutdownFunc) {
 	}).Info("Closing app...")
 
 	// Closing down stuff
+	a.closeWebSockets()
+	a.setHTMLRender(nil)
 
 	if len(f) > 0 {
 		for _, e := range f {
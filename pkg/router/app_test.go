@@ -1,7 +1,13 @@
 package router
 
 import (
+	"bytes"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -121,3 +127,81 @@ func TestOptions(t *testing.T) {
 	assert.Equal(t, 1, len(app.routes))
 	assert.Equal(t, "/hello", app.routes[0].Path)
 }
+
+func TestMaxBodySize(t *testing.T) {
+	app := New(Config{Port: ":8080", MaxBodySize: 8})
+	app.Post("/body", "", func(c *Context) {
+		var b struct {
+			Name string `json:"name"`
+		}
+		err := c.BindJSON(&b)
+		if err != nil {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	app.Route(Route{
+		Path:        "/large",
+		Method:      Post,
+		MaxBodySize: 64,
+		HandlerFunc: func(c *Context) {
+			c.Status(http.StatusOK)
+		},
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/body", strings.NewReader(`{"name":"routey"}`))
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	r = httptest.NewRequest(http.MethodPost, "/body", io.NopCloser(strings.NewReader(`{"name":"routey"}`)))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	r = httptest.NewRequest(http.MethodPost, "/large", strings.NewReader(`{"name":"routey"}`))
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMultipartMemory(t *testing.T) {
+	onDisk := func(app *App) bool {
+		var disk bool
+		app.Post("/upload", "", func(c *Context) {
+			var f struct {
+				File *multipart.FileHeader `form:"file"`
+			}
+			err := c.BindForm(&f)
+			if err != nil {
+				return
+			}
+
+			o, err := f.File.Open()
+			if err != nil {
+				return
+			}
+			defer o.Close()
+
+			_, disk = o.(*os.File)
+			c.Status(http.StatusOK)
+		})
+
+		b := &bytes.Buffer{}
+		m := multipart.NewWriter(b)
+		fw, _ := m.CreateFormFile("file", "routey.txt")
+		fw.Write([]byte("hello routey"))
+		m.Close()
+
+		r := httptest.NewRequest(http.MethodPost, "/upload", b)
+		r.Header.Set("Content-Type", m.FormDataContentType())
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return disk
+	}
+
+	assert.False(t, onDisk(New()))
+	assert.True(t, onDisk(New(Config{Port: ":8080", MultipartMemory: 4})), "files larger than MultipartMemory are stored in temporary files")
+}
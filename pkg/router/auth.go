@@ -0,0 +1,244 @@
+package router
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// The request did not send any credentials
+	ErrNoCredentials = errors.New("no credentials")
+	// The credentials of the request are not valid
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Who made the request, set by the authentication decorators
+//
+//   - Subject: the user, or whatever made the request
+//
+//   - Roles: the roles of the subject
+//
+//   - Scheme: how the subject was authenticated, Basic, APIKey or Bearer
+//
+//   - Claims: the claims of a JWT
+//
+//   - Value: anything returned by a lookup, such as the user, see PrincipalValue
+type Principal struct {
+	Subject string
+	Roles   []string
+	Scheme  string
+	Claims  map[string]any
+	Value   any
+}
+
+// Finds the password and Principal of a user, ok is false when the user does not exist
+type BasicLookupFunc func(c *Context, user string) (password string, p *Principal, ok bool)
+
+// Finds the Principal of an API key, ok is false when the key is not valid
+type APIKeyLookupFunc func(c *Context, key string) (p *Principal, ok bool)
+
+// Configure the BasicAuth decorator
+//
+//   - Lookup: finds the password and Principal of a user
+//
+//   - Realm: the realm sent with WWW-Authenticate, defaults to Restricted
+type BasicAuthConfig struct {
+	Lookup BasicLookupFunc
+	Realm  string
+}
+
+// Configure the APIKeyAuth decorator
+//
+//   - Lookup: finds the Principal of a key, such as APIKeys.Lookup
+//
+//   - Header: the header of the key, defaults to X-API-Key
+//
+//   - Query: the query of the key, empty does not read the key from the query
+//
+//   - Realm: the realm sent with WWW-Authenticate, defaults to Restricted
+type APIKeyConfig struct {
+	Lookup APIKeyLookupFunc
+	Header string
+	Query  string
+	Realm  string
+}
+
+// A set of API keys that can be changed while serving, so keys can be rotated.
+// Keys are kept as their SHA-256, so looking one up does not leak the key through timing.
+type APIKeys struct {
+	keys map[string]Principal
+	mu   sync.RWMutex
+}
+
+// Authenticate requests with HTTP Basic, responding 401 Unauthorized when the credentials are missing or wrong.
+// Passwords are compared in constant time, and a user that does not exist takes as long as a wrong password.
+func BasicAuth(cfg BasicAuthConfig) DecoratorFunc {
+	if cfg.Realm == "" {
+		cfg.Realm = "Restricted"
+	}
+	challenge := `Basic realm=` + strconv.Quote(cfg.Realm) + `, charset="UTF-8"`
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			u, pw, ok := c.request.BasicAuth()
+			if !ok {
+				c.Unauthorized(challenge, ErrNoCredentials)
+				return
+			}
+
+			want, p, found := cfg.Lookup(c, u)
+			match := subtle.ConstantTimeCompare(hashCredential(pw), hashCredential(want)) == 1
+			if !found || !match {
+				c.Unauthorized(challenge, ErrInvalidCredentials)
+				return
+			}
+
+			if p == nil {
+				p = &Principal{}
+			}
+			if p.Subject == "" {
+				p.Subject = u
+			}
+			p.Scheme = "Basic"
+			c.SetPrincipal(p)
+
+			f(c)
+		}
+	}
+}
+
+// Look up users from a map of users to passwords, for BasicAuth
+func BasicUsers(users map[string]string) BasicLookupFunc {
+	return func(c *Context, user string) (string, *Principal, bool) {
+		pw, ok := users[user]
+		return pw, &Principal{Subject: user}, ok
+	}
+}
+
+// Authenticate requests with an API key from a header or the query, responding 401 Unauthorized when it is missing or not valid
+func APIKeyAuth(cfg APIKeyConfig) DecoratorFunc {
+	if cfg.Header == "" {
+		cfg.Header = "X-API-Key"
+	}
+	if cfg.Realm == "" {
+		cfg.Realm = "Restricted"
+	}
+	challenge := `APIKey realm=` + strconv.Quote(cfg.Realm)
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			k := c.request.Header.Get(cfg.Header)
+			if k == "" && cfg.Query != "" {
+				k = c.request.URL.Query().Get(cfg.Query)
+			}
+			if k == "" {
+				c.Unauthorized(challenge, ErrNoCredentials)
+				return
+			}
+
+			p, ok := cfg.Lookup(c, k)
+			if !ok {
+				c.Unauthorized(challenge, ErrInvalidCredentials)
+				return
+			}
+
+			if p == nil {
+				p = &Principal{}
+			}
+			p.Scheme = "APIKey"
+			c.SetPrincipal(p)
+
+			f(c)
+		}
+	}
+}
+
+// Create a set of API keys, each with the Principal it authenticates
+func NewAPIKeys(keys map[string]Principal) *APIKeys {
+	a := &APIKeys{keys: make(map[string]Principal, len(keys))}
+	for k, p := range keys {
+		a.Add(k, p)
+	}
+
+	return a
+}
+
+// Add a key, the old key of a Principal keeps working until it is revoked
+func (a *APIKeys) Add(key string, p Principal) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.keys[hex.EncodeToString(hashCredential(key))] = p
+}
+
+// Revoke a key
+func (a *APIKeys) Revoke(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.keys, hex.EncodeToString(hashCredential(key)))
+}
+
+// Find the Principal of a key, used as the Lookup of APIKeyConfig
+func (a *APIKeys) Lookup(c *Context, key string) (*Principal, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	p, ok := a.keys[hex.EncodeToString(hashCredential(key))]
+	if !ok {
+		return nil, false
+	}
+
+	p.Roles = append([]string(nil), p.Roles...)
+	return &p, true
+}
+
+// Get the Principal of the request, nil when it has not been authenticated
+func (c *Context) Principal() *Principal {
+	return c.principal
+}
+
+// Set the Principal of the request, used by custom authentication
+func (c *Context) SetPrincipal(p *Principal) {
+	c.principal = p
+}
+
+// Get the Value of the Principal of the request as T, such as the user returned by a lookup
+func PrincipalValue[T any](c *Context) (T, bool) {
+	var t T
+	if c.principal == nil {
+		return t, false
+	}
+
+	t, ok := c.principal.Value.(T)
+	return t, ok
+}
+
+// Respond 401 Unauthorized with the WWW-Authenticate challenge, through the error handler
+func (c *Context) Unauthorized(challenge string, err error) {
+	c.writer.Header().Set("WWW-Authenticate", challenge)
+	logWarn(c.logger(), err.Error(), "AUTH")
+	c.HandleError(http.StatusUnauthorized, err)
+}
+
+// Hash a credential so credentials of any length can be compared in constant time
+func hashCredential(v string) []byte {
+	h := sha256.Sum256([]byte(v))
+	return h[:]
+}
+
+// Get the token of an Authorization header with the scheme, such as Bearer
+func authorizationToken(r *http.Request, scheme string) string {
+	s, t, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(s, scheme) {
+		return ""
+	}
+
+	return strings.TrimSpace(t)
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type authUser struct {
+	Name string
+}
+
+// Respond with the Principal of the request
+func authHandler(c *Context) {
+	u, _ := PrincipalValue[authUser](c)
+	c.String(http.StatusOK, "%s %s %v %s", c.Principal().Scheme, c.Principal().Subject, c.Principal().Roles, u.Name)
+}
+
+// The Authorization header of the user and password
+func basicAuthHeader(user string, pw string) map[string]string {
+	return map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pw))}
+}
+
+func TestBasicAuth(t *testing.T) {
+	app := New()
+	app.Add(Get, "/auth", "", authHandler, BasicAuth(BasicAuthConfig{Lookup: BasicUsers(map[string]string{"routey": "secret"}), Realm: "Admin"}))
+	app.Add(Get, "/admin", "", authHandler, BasicAuth(BasicAuthConfig{Lookup: func(c *Context, user string) (string, *Principal, bool) {
+		return "pw", &Principal{Roles: []string{"admin"}, Value: authUser{Name: "Routey"}}, user == "admin"
+	}}))
+
+	w := serveRequest(app, http.MethodGet, "/auth", "", basicAuthHeader("routey", "secret"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Basic routey [] ", w.Body.String())
+
+	cases := map[string]map[string]string{
+		"wrong password": basicAuthHeader("routey", "wrong"),
+		"unknown user":   basicAuthHeader("other", "secret"),
+		"none":           nil,
+		"bearer":         {"Authorization": "Bearer token"},
+	}
+	for n, h := range cases {
+		w = serveRequest(app, http.MethodGet, "/auth", "", h)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, n)
+		assert.Equal(t, `Basic realm="Admin", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"), n)
+	}
+
+	w = serveRequest(app, http.MethodGet, "/admin", "", basicAuthHeader("admin", "pw"))
+	assert.Equal(t, "Basic admin [admin] Routey", w.Body.String())
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	app := New()
+	keys := NewAPIKeys(map[string]Principal{
+		"old-key": {Subject: "service", Roles: []string{"reader"}},
+	})
+	app.Add(Get, "/auth", "", authHandler, APIKeyAuth(APIKeyConfig{Lookup: keys.Lookup, Query: "api_key"}))
+	app.Add(Get, "/header", "", authHandler, APIKeyAuth(APIKeyConfig{Lookup: keys.Lookup}))
+
+	w := serveRequest(app, http.MethodGet, "/auth", "", map[string]string{"X-API-Key": "old-key"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "APIKey service [reader] ", w.Body.String())
+
+	keys.Add("new-key", Principal{Subject: "service"})
+	keys.Revoke("old-key")
+
+	w = serveRequest(app, http.MethodGet, "/auth?api_key=new-key", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveRequest(app, http.MethodGet, "/auth", "", map[string]string{"X-API-Key": "old-key"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `APIKey realm="Restricted"`, w.Header().Get("WWW-Authenticate"))
+
+	w = serveRequest(app, http.MethodGet, "/header?api_key=new-key", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestContextPrincipal(t *testing.T) {
+	c, _ := cookieContext(New())
+	assert.Nil(t, c.Principal())
+
+	_, ok := PrincipalValue[authUser](c)
+	assert.False(t, ok)
+
+	c.SetPrincipal(&Principal{Subject: "routey", Value: authUser{Name: "Routey"}})
+	u, ok := PrincipalValue[authUser](c)
+	assert.True(t, ok)
+	assert.Equal(t, "Routey", u.Name)
+
+	_, ok = PrincipalValue[string](c)
+	assert.False(t, ok)
+}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// The Principal of the request is not allowed to use the route
+var ErrForbidden = errors.New("forbidden")
+
+// Decides whether the Principal of a request can use a route, consulted after the decorators
+// of the route and before its HandlerFunc, for routes with Roles or Policies
+type Authorizer interface {
+	Authorize(c *Context, r *Route) error
+}
+
+// An Authorizer as a func
+type AuthorizerFunc func(c *Context, r *Route) error
+
+// An attribute based policy, given the Context and the params of the route
+type PolicyFunc func(c *Context, params map[string]string) bool
+
+// The default Authorizer, allows a Principal with any of the Roles of the route
+//
+//   - Inherits: the roles each role includes, such as admin including editor
+type RoleAuthorizer struct {
+	Inherits map[string][]string
+}
+
+// Authorize the request
+func (f AuthorizerFunc) Authorize(c *Context, r *Route) error {
+	return f(c, r)
+}
+
+// Allow a Principal that has, or inherits, any of the Roles of the route
+func (a RoleAuthorizer) Authorize(c *Context, r *Route) error {
+	if len(r.Roles) == 0 {
+		return nil
+	}
+
+	p := c.Principal()
+	if p == nil {
+		return ErrForbidden
+	}
+
+	seen := make(map[string]bool)
+	roles := slices.Clone(p.Roles)
+	for len(roles) > 0 {
+		n := roles[len(roles)-1]
+		roles = roles[:len(roles)-1]
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+
+		if slices.Contains(r.Roles, n) {
+			return nil
+		}
+		roles = append(roles, a.Inherits[n]...)
+	}
+
+	return ErrForbidden
+}
+
+// Set the Authorizer consulted for routes with Roles or Policies, defaults to a RoleAuthorizer
+func (a *App) SetAuthorizer(z Authorizer) {
+	a.authorizer = z
+}
+
+// Get the routes without Roles or Policies, which any request that reaches them can use
+func (a *App) UnprotectedRoutes() []Route {
+	r := make([]Route, 0)
+	for _, e := range a.routes {
+		if !e.protected() {
+			r = append(r, e)
+		}
+	}
+
+	return r
+}
+
+// Require any of the roles to use the route
+func (r Route) Require(roles ...string) Route {
+	r.Roles = append(slices.Clone(r.Roles), roles...)
+	return r
+}
+
+// Require the policies to allow the request to use the route
+func (r Route) Allow(p ...PolicyFunc) Route {
+	r.Policies = append(slices.Clone(r.Policies), p...)
+	return r
+}
+
+// Does the route have Roles or Policies
+func (r *Route) protected() bool {
+	return len(r.Roles) > 0 || len(r.Policies) > 0
+}
+
+// Wrap the HandlerFunc of the route, authorizing the request before it is handled
+func (a *App) authorized(e *Route) HandlerFunc {
+	if !e.protected() {
+		return e.HandlerFunc
+	}
+
+	return func(c *Context) {
+		z := a.authorizer
+		if z == nil {
+			z = RoleAuthorizer{}
+		}
+
+		err := z.Authorize(c, e)
+		if err == nil {
+			for _, p := range e.Policies {
+				if !p(c, c.params) {
+					err = ErrForbidden
+					break
+				}
+			}
+		}
+		if err != nil {
+			logWarn(c.logger(), fmt.Sprintf("%s: %s%s %s", e.Method.String(), e.Path, e.Params, err.Error()), "AUTH")
+			c.HandleError(http.StatusForbidden, err)
+			return
+		}
+
+		e.HandlerFunc.Serve(c)
+	}
+}
+
+// Log the routes that are not protected, when any route is
+func (a *App) reportUnprotected() {
+	u := a.UnprotectedRoutes()
+	if a.authorizer == nil && len(u) == len(a.routes) {
+		return
+	}
+
+	for _, e := range u {
+		logWarn(a.logger, fmt.Sprintf("unprotected route %s: %s%s", e.Method.String(), e.Path, e.Params), "AUTH")
+	}
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	app := New()
+	auth := func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.GetHeader("X-Roles") != "" {
+				c.SetPrincipal(&Principal{Subject: "routey", Roles: []string{c.GetHeader("X-Roles")}})
+			}
+			f(c)
+		}
+	}
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	app.Route(Route{Path: "/public", Method: Get, HandlerFunc: ok, DecoratorFunc: auth})
+	app.Route(Route{Path: "/admin", Method: Get, HandlerFunc: ok, DecoratorFunc: auth}.Require("admin"))
+	app.Route(Route{Path: "/edit", Method: Get, HandlerFunc: ok, DecoratorFunc: auth, Roles: []string{"editor"}}.Require("admin"))
+	owner := func(c *Context, params map[string]string) bool {
+		return c.Principal() != nil && c.Principal().Subject == params["user"]
+	}
+	app.Route(Route{Path: "/users", Params: "/:user", Method: Get, HandlerFunc: ok, DecoratorFunc: auth}.Allow(owner))
+
+	cases := []struct {
+		path   string
+		role   string
+		status int
+	}{
+		{"/public", "", http.StatusOK},
+		{"/admin", "", http.StatusForbidden},
+		{"/admin", "editor", http.StatusForbidden},
+		{"/admin", "admin", http.StatusOK},
+		{"/edit", "editor", http.StatusOK},
+		{"/edit", "admin", http.StatusOK},
+		{"/edit", "viewer", http.StatusForbidden},
+		{"/users/routey", "viewer", http.StatusOK},
+		{"/users/other", "viewer", http.StatusForbidden},
+		{"/users/routey", "", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		w := serveRequest(app, http.MethodGet, tc.path, "", map[string]string{"X-Roles": tc.role})
+		assert.Equal(t, tc.status, w.Code, tc.path, tc.role)
+	}
+}
+
+func TestRoleAuthorizerInherits(t *testing.T) {
+	app := New()
+	auth := func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.GetHeader("X-Roles") != "" {
+				c.SetPrincipal(&Principal{Subject: "routey", Roles: []string{c.GetHeader("X-Roles")}})
+			}
+			f(c)
+		}
+	}
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	app.Route(Route{Path: "/admin", Method: Get, HandlerFunc: ok, DecoratorFunc: auth}.Require("admin"))
+	app.Route(Route{Path: "/edit", Method: Get, HandlerFunc: ok, DecoratorFunc: auth, Roles: []string{"editor"}}.Require("admin"))
+	app.SetAuthorizer(RoleAuthorizer{Inherits: map[string][]string{
+		"owner": {"admin"},
+		"admin": {"editor", "owner"},
+	}})
+
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/admin", "", map[string]string{"X-Roles": "owner"}).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/edit", "", map[string]string{"X-Roles": "owner"}).Code)
+	assert.Equal(t, http.StatusForbidden, serveRequest(app, http.MethodGet, "/admin", "", map[string]string{"X-Roles": "editor"}).Code)
+}
+
+func TestSetAuthorizer(t *testing.T) {
+	app := New()
+	auth := func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.GetHeader("X-Roles") != "" {
+				c.SetPrincipal(&Principal{Subject: "routey", Roles: []string{c.GetHeader("X-Roles")}})
+			}
+			f(c)
+		}
+	}
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	app.Route(Route{Path: "/public", Method: Get, HandlerFunc: ok, DecoratorFunc: auth})
+	app.Route(Route{Path: "/admin", Method: Get, HandlerFunc: ok, DecoratorFunc: auth}.Require("admin"))
+	deny := errors.New("closed")
+	var route *Route
+	app.SetAuthorizer(AuthorizerFunc(func(c *Context, r *Route) error {
+		route = r
+		return deny
+	}))
+
+	var err error
+	app.SetErrorHandler(func(c *Context, s int, e error) {
+		err = e
+		c.String(s, e.Error())
+	})
+
+	w := serveRequest(app, http.MethodGet, "/admin", "", map[string]string{"X-Roles": "admin"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "closed", w.Body.String())
+	assert.ErrorIs(t, err, deny)
+	assert.Equal(t, []string{"admin"}, route.Roles)
+
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/public", "", nil).Code)
+}
+
+func TestRouteRequire(t *testing.T) {
+	r := Route{Path: "/", Roles: []string{"a"}}
+	a := r.Require("b")
+	b := r.Require("c")
+
+	assert.Equal(t, []string{"a"}, r.Roles)
+	assert.Equal(t, []string{"a", "b"}, a.Roles)
+	assert.Equal(t, []string{"a", "c"}, b.Roles)
+	assert.Len(t, r.Allow(func(c *Context, p map[string]string) bool { return true }).Policies, 1)
+	assert.Empty(t, r.Policies)
+}
+
+func TestUnprotectedRoutes(t *testing.T) {
+	app := New()
+	auth := func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.GetHeader("X-Roles") != "" {
+				c.SetPrincipal(&Principal{Subject: "routey", Roles: []string{c.GetHeader("X-Roles")}})
+			}
+			f(c)
+		}
+	}
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	app.Route(Route{Path: "/public", Method: Get, HandlerFunc: ok, DecoratorFunc: auth})
+	app.Route(Route{Path: "/admin", Method: Get, HandlerFunc: ok, DecoratorFunc: auth}.Require("admin"))
+
+	u := app.UnprotectedRoutes()
+	assert.Len(t, u, 1)
+	assert.Equal(t, "/public", u[0].Path)
+
+	b := &bytes.Buffer{}
+	app.logger.SetOutput(b)
+	app.reportUnprotected()
+	assert.Contains(t, b.String(), "unprotected route GET: /public")
+	assert.NotContains(t, b.String(), "/admin")
+
+	b.Reset()
+	app = New()
+	app.logger.SetOutput(b)
+	app.Get("/", "", func(c *Context) {})
+	app.reportUnprotected()
+	assert.Empty(t, b.String())
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// A component renders itself as HTML, such as a component generated by templ
+type Component interface {
+	Render(ctx context.Context, w io.Writer) error
+}
+
+// Use a func as a Component
+type ComponentFunc func(ctx context.Context, w io.Writer) error
+
+// Component renderer
+//
+//   - Component: the component being rendered
+//
+//   - Context: given to the component, the request's context when rendered by Context.HTML
+//
+//   - Stream: write the component as it renders, otherwise it is buffered
+type ComponentRender struct {
+	Component Component
+	Context   context.Context
+	Stream    bool
+}
+
+// Template engine for Components
+//
+//   - Pages: create the component of a page from the data given to Context.HTML
+//
+//   - Layout: wraps the component of every page, nil renders pages on their own
+//
+// The FuncMap of the App is given to components through their context, get it with TemplateFuncs.
+type Components struct {
+	Pages  map[string]func(d any) Component
+	Layout func(page Component, d any) Component
+
+	funcMap template.FuncMap
+}
+
+// The key of the FuncMap in the context of a Component
+type funcMapKey struct{}
+
+// Render the component
+func (f ComponentFunc) Render(ctx context.Context, w io.Writer) error {
+	return f(ctx, w)
+}
+
+// Render the component
+func (c ComponentRender) Render(w http.ResponseWriter) error {
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return renderTemplate(w, c, c.Stream, func(o io.Writer) error {
+		return c.Component.Render(ctx, o)
+	})
+}
+
+// Write the content type
+func (c ComponentRender) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
+
+// Keep the FuncMap of the App for the components
+func (c *Components) Load(o TemplateOptions) error {
+	c.funcMap = o.FuncMap
+	return nil
+}
+
+// Create an instance of the HTMLRenderer, for the page.
+// A Component given as the data is rendered when there is no page with the name.
+func (c *Components) Instance(n string, d any) Renderer {
+	var p Component
+
+	f, ok := c.Pages[n]
+	switch {
+	case ok:
+		p = f(d)
+	case d != nil:
+		p, _ = d.(Component)
+	}
+	if p == nil {
+		return htmlError{err: templateNotDefined(n)}
+	}
+
+	if c.Layout != nil {
+		p = c.Layout(p, d)
+	}
+
+	return ComponentRender{
+		Component: ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			return p.Render(context.WithValue(ctx, funcMapKey{}, c.funcMap), w)
+		}),
+	}
+}
+
+// Get the FuncMap of the App, from the context of a Component
+func TemplateFuncs(ctx context.Context) template.FuncMap {
+	f, _ := ctx.Value(funcMapKey{}).(template.FuncMap)
+	return f
+}
+
+// Render a Component, with the FuncMap of the App in its context.
+// The component is buffered or streamed like templates, see App.SetHTMLMode.
+func (c *Context) Component(s int, p Component) {
+	r := ComponentRender{Component: p, Context: c.request.Context()}
+	if c.app != nil {
+		r.Context = context.WithValue(r.Context, funcMapKey{}, c.app.funcMap)
+		r.Stream = c.app.htmlMode == HTMLStreamed
+	}
+
+	c.RenderWith(s, r)
+}
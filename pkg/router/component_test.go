@@ -0,0 +1,105 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type componentMock struct {
+	Name string
+}
+
+func (c componentMock) Render(ctx context.Context, w io.Writer) error {
+	_, err := fmt.Fprintf(w, "<p>%s %v</p>", c.Name, ctx.Err() == nil)
+	return err
+}
+
+func TestComponents(t *testing.T) {
+	app := New()
+	app.SetTemplateEngine(&Components{
+		Pages: map[string]func(d any) Component{
+			"hello": func(d any) Component {
+				return componentMock{Name: d.(string)}
+			},
+			"asset": func(d any) Component {
+				return ComponentFunc(func(ctx context.Context, w io.Writer) error {
+					f := TemplateFuncs(ctx)["asset"].(func(string) string)
+					_, err := io.WriteString(w, f("/app.js"))
+					return err
+				})
+			},
+			"broken": func(d any) Component {
+				return ComponentFunc(func(ctx context.Context, w io.Writer) error {
+					return errors.New("broken")
+				})
+			},
+		},
+		Layout: func(p Component, d any) Component {
+			return ComponentFunc(func(ctx context.Context, w io.Writer) error {
+				io.WriteString(w, "<main>")
+				err := p.Render(ctx, w)
+				io.WriteString(w, "</main>")
+				return err
+			})
+		},
+	})
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+	app.Get("/data", "", func(c *Context) {
+		c.HTML(http.StatusOK, "", componentMock{Name: "data"})
+	})
+
+	w := serveRequest(app, http.MethodGet, "/pages/hello", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<main><p>routey true</p></main>", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+
+	w = serveRequest(app, http.MethodGet, "/data", "", nil)
+	assert.Equal(t, "<main><p>data true</p></main>", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/pages/asset", "", nil)
+	assert.Equal(t, "<main>/app.js</main>", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/pages/broken", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "buffered components respond through the error handler")
+	assert.NotContains(t, w.Body.String(), "<main>")
+
+	app.SetHTMLMode(HTMLStreamed)
+	w = serveRequest(app, http.MethodGet, "/pages/broken", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<main></main>", w.Body.String())
+	app.SetHTMLMode(HTMLBuffered)
+
+	w = serveRequest(app, http.MethodGet, "/pages/missing", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestContextComponent(t *testing.T) {
+	app := New()
+	app.Get("/component", "", func(c *Context) {
+		c.Component(http.StatusCreated, componentMock{Name: "routey"})
+	})
+
+	w := serveRequest(app, http.MethodGet, "/component", "", nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "<p>routey true</p>", w.Body.String())
+
+	app.Get("/broken", "", func(c *Context) {
+		c.Component(http.StatusCreated, ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<p>")
+			return errors.New("broken")
+		}))
+	})
+
+	w = serveRequest(app, http.MethodGet, "/broken", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "<p>")
+}
@@ -0,0 +1,437 @@
+package router
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings supported by Compress
+const (
+	EncodingBrotli  = "br"
+	EncodingZstd    = "zstd"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// Configure how responses are compressed
+//
+//   - Encodings: the encodings offered, in order of preference, defaults to br, zstd, gzip and deflate
+//
+//   - MinLength: bodies smaller than this many bytes are not compressed, defaults to 1024
+//
+//   - ExcludedTypes: content types that are already compressed, matched by prefix, defaults to DefaultExcludedTypes
+type CompressConfig struct {
+	Encodings     []string
+	MinLength     int
+	ExcludedTypes []string
+}
+
+// Content types that are not compressed by default, as they are already compressed
+var DefaultExcludedTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"image/avif",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"application/octet-stream",
+}
+
+// A pooled encoder
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Pools of encoders, encoders are reset before they are reused
+var encoders = map[string]*sync.Pool{
+	EncodingBrotli: {New: func() any {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	EncodingZstd: {New: func() any {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+	EncodingGzip: {New: func() any {
+		return gzip.NewWriter(nil)
+	}},
+	EncodingDeflate: {New: func() any {
+		return zlib.NewWriter(nil)
+	}},
+}
+
+// Compress responses with the best encoding accepted by the client.
+// Bodies below the MinLength and content types that are already compressed are sent as is,
+// responses that are flushed, such as streams and Server-Sent Events, are compressed as they are written.
+// Request bodies sent with a Content-Encoding are decompressed before they are bound.
+//
+// Use it on a route as the DecoratorFunc, or on every route with App.Decorate.
+func Compress(cfg ...CompressConfig) DecoratorFunc {
+	o := CompressConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+	if o.Encodings == nil {
+		o.Encodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip, EncodingDeflate}
+	}
+	if o.MinLength <= 0 {
+		o.MinLength = 1024
+	}
+	if o.ExcludedTypes == nil {
+		o.ExcludedTypes = DefaultExcludedTypes
+	}
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if !c.decompressBody() {
+				return
+			}
+
+			if c.request.Header.Get("Upgrade") != "" {
+				f(c)
+				return
+			}
+
+			addVary(c.writer.Header(), "Accept-Encoding")
+
+			e := negotiateEncoding(c.request, o.Encodings)
+			if e == "" {
+				f(c)
+				return
+			}
+
+			w := &compressWriter{
+				ResponseWriter: c.writer,
+				cfg:            &o,
+				encoding:       e,
+				method:         c.request.Method,
+			}
+			p := c.writer
+			c.writer = w
+			defer func() {
+				c.writer = p
+				err := w.Close()
+				if err != nil {
+					logError(c.logger(), err.Error(), "COMPRESS")
+				}
+			}()
+
+			f(c)
+		}
+	}
+}
+
+// Compresses the body once it is known to be worth compressing
+//
+//   - buf: the start of the body, held until MinLength is reached or it is flushed
+//
+//   - decided: has it been decided whether to compress the body?
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *CompressConfig
+	encoding string
+	method   string
+
+	enc     encoder
+	buf     []byte
+	status  int
+	decided bool
+}
+
+// Hold the status until the body is written
+func (w *compressWriter) WriteHeader(s int) {
+	if s >= 100 && s < 200 {
+		w.ResponseWriter.WriteHeader(s)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+
+	w.status = s
+	if w.decided {
+		w.ResponseWriter.WriteHeader(s)
+		return
+	}
+
+	n, err := strconv.Atoi(w.Header().Get("Content-Length"))
+	if err == nil && n < w.cfg.MinLength {
+		w.decide(false)
+	}
+}
+
+// Write the body, compressing it when decided
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.cfg.MinLength {
+			return len(b), nil
+		}
+
+		err := w.decide(true)
+		return len(b), err
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush the encoder and the underlying writer, a flushed body is always compressed
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack the connection, the body is no longer compressed
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Get the underlying writer, used by http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Finish the body, closing the encoder
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		err := w.decide(len(w.buf) >= w.cfg.MinLength)
+		if err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.enc.Reset(nil)
+	encoders[w.encoding].Put(w.enc)
+	w.enc = nil
+
+	return err
+}
+
+// Decide whether to compress, then write the status and any held body
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if compress && w.compressible() {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+
+		t := h.Get("ETag")
+		if t != "" && !strings.HasPrefix(t, "W/") {
+			h.Set("ETag", "W/"+t)
+		}
+
+		w.enc = encoders[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+
+	return err
+}
+
+// Can the response be compressed?
+func (w *compressWriter) compressible() bool {
+	if w.method == http.MethodHead {
+		return false
+	}
+
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	t := strings.ToLower(h.Get("Content-Type"))
+	for _, e := range w.cfg.ExcludedTypes {
+		if strings.HasPrefix(t, e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Replace the body of a request sent with a Content-Encoding with the decompressed body,
+// returns false if the encoding is not supported or the body is not valid
+func (c *Context) decompressBody() bool {
+	e := strings.ToLower(strings.TrimSpace(c.request.Header.Get("Content-Encoding")))
+	if e == "" || e == "identity" || c.request.Body == nil || c.request.Body == http.NoBody {
+		return true
+	}
+
+	var (
+		r   io.Reader
+		err error
+	)
+	switch e {
+	case EncodingGzip, "x-gzip":
+		r, err = gzip.NewReader(c.request.Body)
+	case EncodingDeflate:
+		r, err = zlib.NewReader(c.request.Body)
+	case EncodingBrotli:
+		r = brotli.NewReader(c.request.Body)
+	case EncodingZstd:
+		var d *zstd.Decoder
+		d, err = zstd.NewReader(c.request.Body, zstd.WithDecoderConcurrency(1))
+		if err == nil {
+			r = d.IOReadCloser()
+		}
+	default:
+		c.HandleError(http.StatusUnsupportedMediaType, errors.New("unsupported content encoding "+e))
+		return false
+	}
+	if err != nil {
+		c.HandleError(http.StatusBadRequest, err)
+		return false
+	}
+
+	c.request.Body = decompressedBody{Reader: r, body: c.request.Body}
+	c.request.Header.Del("Content-Encoding")
+	c.request.Header.Del("Content-Length")
+	c.request.ContentLength = -1
+
+	return c.limitBody()
+}
+
+// A decompressed request body, closing the decoder and the original body
+type decompressedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+// Close the decoder and the original body
+func (d decompressedBody) Close() error {
+	c, ok := d.Reader.(io.Closer)
+	if ok {
+		c.Close()
+	}
+
+	return d.body.Close()
+}
+
+// Get the quality the client gives an encoding in Accept-Encoding, identity is accepted unless refused
+func encodingQuality(r *http.Request, e string) float64 {
+	q := -1.0
+	w := -1.0
+
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, p := range strings.Split(v, ",") {
+			n, ps, _ := strings.Cut(strings.TrimSpace(p), ";")
+			n = strings.ToLower(strings.TrimSpace(n))
+			if n == "" {
+				continue
+			}
+
+			f := 1.0
+			for _, a := range strings.Split(ps, ";") {
+				k, v, _ := strings.Cut(strings.TrimSpace(a), "=")
+				if strings.TrimSpace(k) != "q" {
+					continue
+				}
+
+				x, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err == nil && x >= 0 && x <= 1 {
+					f = x
+				}
+			}
+
+			switch {
+			case n == e || (e == EncodingGzip && n == "x-gzip"):
+				q = f
+			case n == "*":
+				w = f
+			}
+		}
+	}
+
+	switch {
+	case q >= 0:
+		return q
+	case w >= 0:
+		return w
+	case e == "identity":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Get the offered encoding the client accepts with the highest quality, ties go to the first offered
+func negotiateEncoding(r *http.Request, offered []string) string {
+	b := ""
+	bq := 0.0
+
+	for _, e := range offered {
+		q := encodingQuality(r, e)
+		if q > bq {
+			b = e
+			bq = q
+		}
+	}
+
+	return b
+}
+
+// Does the client accept the encoding?
+func acceptsEncoding(r *http.Request, e string) bool {
+	return encodingQuality(r, e) > 0
+}
@@ -0,0 +1,174 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+var compressBody = strings.Repeat("routey ", 500)
+
+func decode(t *testing.T, e string, b []byte) string {
+	var r io.Reader
+	var err error
+	switch e {
+	case EncodingGzip:
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case EncodingDeflate:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(b))
+	case EncodingZstd:
+		r, err = zstd.NewReader(bytes.NewReader(b))
+	default:
+		return string(b)
+	}
+	assert.NoError(t, err)
+
+	d, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(d)
+}
+
+func TestCompress(t *testing.T) {
+	app := New()
+	app.Decorate(Compress())
+	app.Get("/large", "", func(c *Context) {
+		c.Render(http.StatusOK, compressBody)
+	})
+
+	cases := map[string]string{
+		"gzip":                    EncodingGzip,
+		"deflate":                 EncodingDeflate,
+		"br":                      EncodingBrotli,
+		"zstd":                    EncodingZstd,
+		"gzip, br, zstd":          EncodingBrotli,
+		"gzip;q=1, br;q=0.5":      EncodingGzip,
+		"*":                       EncodingBrotli,
+		"*, br;q=0":               EncodingZstd,
+		"identity":                "",
+		"":                        "",
+		"compress, x-gzip;q=0.2":  EncodingGzip,
+		"gzip;q=0, deflate;q=0.1": EncodingDeflate,
+	}
+
+	for accept, e := range cases {
+		w := serveRequest(app, http.MethodGet, "/large", "", map[string]string{"Accept-Encoding": accept})
+		assert.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Equal(t, e, w.Header().Get("Content-Encoding"), accept)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), accept)
+		assert.Equal(t, compressBody, decode(t, e, w.Body.Bytes()), accept)
+		if e != "" {
+			assert.Less(t, w.Body.Len(), len(compressBody), accept)
+		}
+	}
+}
+
+func TestCompressSkipped(t *testing.T) {
+	app := New()
+	app.Decorate(Compress())
+	app.Get("/small", "", func(c *Context) {
+		c.Render(http.StatusOK, "routey")
+	})
+	app.Get("/image", "", func(c *Context) {
+		c.Header("Content-Type", "image/png")
+		c.Render(http.StatusOK, compressBody)
+	})
+
+	w := serveRequest(app, http.MethodGet, "/small", "", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "routey", w.Body.String())
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+	w = serveRequest(app, http.MethodGet, "/image", "", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, compressBody, w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/missing", "", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCompressStream(t *testing.T) {
+	app := New()
+	app.Decorate(Compress())
+	app.Get("/stream", "", func(c *Context) {
+		c.SSEvent("message", "routey")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/stream", "", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "event: message\ndata: routey\n\n", decode(t, EncodingGzip, w.Body.Bytes()))
+}
+
+func TestCompressStatic(t *testing.T) {
+	app := New()
+	app.Decorate(Compress(CompressConfig{MinLength: 1}))
+	app.StaticFS("/static", staticMockFS)
+
+	w := serveRequest(app, http.MethodGet, "/static/app.js", "", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.True(t, strings.HasPrefix(w.Header().Get("ETag"), `W/"`))
+	assert.Equal(t, "console.log('routey')", decode(t, EncodingGzip, w.Body.Bytes()))
+
+	w = serveRequest(app, http.MethodGet, "/static/app.js", "", map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=0-6"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "console", w.Body.String())
+}
+
+func TestCompressRequestBody(t *testing.T) {
+	app := New()
+	app.Decorate(Compress())
+	app.Post("/echo", "", func(c *Context) {
+		b, err := c.Body()
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		c.RenderBytes(http.StatusOK, b)
+	})
+
+	b := &bytes.Buffer{}
+	g := gzip.NewWriter(b)
+	g.Write([]byte(`{"name":"routey"}`))
+	g.Close()
+
+	w := serveRequest(app, http.MethodPost, "/echo", b.String(), map[string]string{"Content-Encoding": "gzip"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"name":"routey"}`, w.Body.String())
+
+	w = serveRequest(app, http.MethodPost, "/echo", "routey", map[string]string{"Content-Encoding": "gzip"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/echo", "routey", map[string]string{"Content-Encoding": "compress"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestChain(t *testing.T) {
+	o := make([]string, 0)
+	d := func(n string) DecoratorFunc {
+		return func(f HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				o = append(o, n)
+				f(c)
+			}
+		}
+	}
+
+	Chain(d("a"), nil, d("b"))(func(c *Context) {
+		o = append(o, "handler")
+	})(&Context{})
+	assert.Equal(t, []string{"a", "b", "handler"}, o)
+}
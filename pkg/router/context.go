@@ -2,8 +2,6 @@ package router
 
 import (
 	"bytes"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -19,8 +17,7 @@ import (
 
 	"github.com/joseph-beck/routey/pkg/binding"
 	errs "github.com/joseph-beck/routey/pkg/error"
-	"github.com/pelletier/go-toml/v2"
-	"gopkg.in/yaml.v3"
+	"github.com/sirupsen/logrus"
 )
 
 // A Context provides
@@ -34,6 +31,14 @@ import (
 //   - queryCache: a cache of queries for this request
 //
 //   - queryCached: has the queryCache been made?
+//
+//   - body: a cache of the body, made when the body is read by Body
+//
+//   - session: the Session of the request, set by the Sessions decorator
+//
+//   - csrf: the CSRF token of the request, set by the CSRF decorator
+//
+//   - principal: who made the request, set by the authentication decorators
 type Context struct {
 	app   *App
 	route *Route
@@ -48,6 +53,13 @@ type Context struct {
 
 	queryCache  url.Values
 	queryCached bool
+
+	body []byte
+
+	session *Session
+	csrf    *csrfState
+
+	principal *Principal
 }
 
 // Reset the current Context
@@ -62,6 +74,13 @@ func (c *Context) Reset() {
 
 	c.queryCache = nil
 	c.queryCached = false
+
+	c.body = nil
+
+	c.session = nil
+	c.csrf = nil
+
+	c.principal = nil
 }
 
 // Copy the current Context and give a pointer to the copy
@@ -79,6 +98,13 @@ func (c *Context) Copy() *Context {
 
 		queryCache:  c.queryCache,
 		queryCached: c.queryCached,
+
+		body: c.body,
+
+		session: c.session,
+		csrf:    c.csrf,
+
+		principal: c.principal,
 	}
 }
 
@@ -134,8 +160,12 @@ func (c *Context) MustGet(k string) (any, error) {
 	return v, nil
 }
 
-// Get the body of the request
+// Get the body of the request, the body is cached so it can be read again
 func (c *Context) Body() ([]byte, error) {
+	if c.body != nil {
+		return c.body, nil
+	}
+
 	if c.request.Body == nil {
 		return nil, errors.New("empty body")
 	}
@@ -145,11 +175,47 @@ func (c *Context) Body() ([]byte, error) {
 		return nil, err
 	}
 
-	buff := bytes.NewBuffer(b)
-	c.request.Body = io.NopCloser(buff)
-	n := bytes.NewBuffer(b)
+	c.body = b
+	c.request.Body = io.NopCloser(bytes.NewReader(b))
 
-	return n.Bytes(), nil
+	return b, nil
+}
+
+// Limit the size of the request body, returns false if the request is too large
+func (c *Context) limitBody() bool {
+	n := c.app.maxBodySize
+	if c.route.MaxBodySize > 0 {
+		n = c.route.MaxBodySize
+	}
+	if n <= 0 || c.request.Body == nil {
+		return true
+	}
+
+	if c.request.ContentLength > n {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return false
+	}
+
+	c.request.Body = http.MaxBytesReader(c.writer, c.request.Body, n)
+	return true
+}
+
+// Get the memory to use when parsing multipart forms
+func (c *Context) multipartMemory() int64 {
+	if c.app == nil || c.app.multipartMemory <= 0 {
+		return binding.MultipartMemory
+	}
+
+	return c.app.multipartMemory
+}
+
+// Get the logger of the App
+func (c *Context) logger() *logrus.Logger {
+	if c.app == nil {
+		return logrus.StandardLogger()
+	}
+
+	return c.app.logger
 }
 
 // Get the method of the route
@@ -251,6 +317,18 @@ func (c *Context) AbortWithError(s int, e error) {
 	c.Status(s)
 }
 
+// Abort with a status and an error, the error is responded with by the App's ErrorHandlerFunc
+func (c *Context) HandleError(s int, e error) {
+	c.state = Aborted
+
+	if c.app == nil || c.app.errorHandler == nil {
+		defaultErrorHandler(c, s, e)
+		return
+	}
+
+	c.app.errorHandler(c, s, e)
+}
+
 // Respond with just a status
 func (c *Context) Status(s int) {
 	c.status = s
@@ -306,68 +384,90 @@ func (c *Context) String(s int, f string, v ...any) {
 	c.RenderBytes(s, []byte(f))
 }
 
-// Render response in a JSON format from a body
-func (c *Context) JSON(s int, b any) {
-	writeContentType(c.writer, jsonContentType)
+// Render with the given Renderer and status.
+// If the Renderer fails before writing, the error is handled by the App with a 500,
+// and only a Content-Type written by the Renderer is removed.
+func (c *Context) RenderWith(s int, r Renderer) {
+	w := &renderWriter{ResponseWriter: c.writer, status: s}
+	ct, set := c.writer.Header()["Content-Type"]
 
-	j, err := json.Marshal(b)
+	err := r.Render(w)
 	if err != nil {
-		c.Status(http.StatusBadRequest)
+		logError(c.logger(), fmt.Sprintf("%T: %s", r, err.Error()), "RENDER")
+
+		if w.written {
+			c.Abort()
+			return
+		}
+
+		if set {
+			c.writer.Header()["Content-Type"] = ct
+		} else {
+			c.writer.Header().Del("Content-Type")
+		}
+		c.HandleError(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.RenderBytes(s, j)
+	w.WriteHeader(s)
+	c.status = s
+}
+
+// Render response in a JSON format from a body
+func (c *Context) JSON(s int, b any) {
+	c.RenderWith(s, JSON{Data: b})
 }
 
 // Render XML
 func (c *Context) XML(s int, b any) {
-	writeContentType(c.writer, xmlContentType)
-
-	x, err := xml.Marshal(b)
-	if err != nil {
-		c.Status(http.StatusBadRequest)
-		return
-	}
-
-	c.RenderBytes(s, x)
+	c.RenderWith(s, XML{Data: b})
 }
 
 // Render YAML
 func (c *Context) YAML(s int, b any) {
-	writeContentType(c.writer, yamlContentType)
-
-	y, err := yaml.Marshal(b)
-	if err != nil {
-		c.Status(http.StatusBadRequest)
-		return
-	}
-
-	c.RenderBytes(s, y)
+	c.RenderWith(s, YAML{Data: b})
 }
 
 // Render TOML
 func (c *Context) TOML(s int, b any) {
-	writeContentType(c.writer, tomlContentType)
+	c.RenderWith(s, TOML{Data: b})
+}
 
-	t, err := toml.Marshal(b)
-	if err != nil {
-		c.Status(http.StatusBadRequest)
-		return
-	}
+// Render MessagePack
+func (c *Context) MsgPack(s int, b any) {
+	c.RenderWith(s, MsgPack{Data: b})
+}
+
+// Render CBOR
+func (c *Context) CBOR(s int, b any) {
+	c.RenderWith(s, CBOR{Data: b})
+}
 
-	c.RenderBytes(s, t)
+// Render Protocol Buffers, the body must be a proto.Message
+func (c *Context) ProtoBuf(s int, b any) {
+	c.RenderWith(s, ProtoBuf{Data: b})
 }
 
 // Render HTML with a given file
 func (c *Context) HTML(s int, n string, d any) {
-	i := c.app.htmlRender.Instance(n, d)
-	c.Status(s)
-
-	err := i.Render(c.writer)
-	if err != nil {
-		c.Error(errs.HTMLError)
-		c.Abort()
+	r := c.app.htmlRender.Instance(n, d)
+
+	switch p := r.(type) {
+	case HTML:
+		p.Stream = c.app.htmlMode == HTMLStreamed
+		r = p
+	case Text:
+		p.Stream = c.app.htmlMode == HTMLStreamed
+		r = p
+	case ComponentRender:
+		if p.Context == nil {
+			p.Context = c.request.Context()
+		}
+		p.Stream = c.app.htmlMode == HTMLStreamed
+		r = p
 	}
+
+	c.RenderWith(s, r)
 }
 
 // Serve the user a local file
@@ -405,7 +505,7 @@ func (c *Context) SaveFile(f *multipart.FileHeader, p string) error {
 // Get a form file
 func (c *Context) FormFile(n string) (*multipart.FileHeader, error) {
 	if c.request.MultipartForm == nil {
-		err := c.request.ParseMultipartForm(32 << 30)
+		err := c.request.ParseMultipartForm(c.multipartMemory())
 		if err != nil {
 			return nil, err
 		}
@@ -421,7 +521,7 @@ func (c *Context) FormFile(n string) (*multipart.FileHeader, error) {
 
 // Get a multipart form
 func (c *Context) MultipartForm() (*multipart.Form, error) {
-	err := c.request.ParseMultipartForm(32 << 30)
+	err := c.request.ParseMultipartForm(c.multipartMemory())
 	if err != nil {
 		return nil, err
 	}
@@ -430,7 +530,7 @@ func (c *Context) MultipartForm() (*multipart.Form, error) {
 	return f, nil
 }
 
-// Get a value from the cookies of the request
+// Get a value from the cookies of the request, unescaping a value set with SetCookie
 func (c *Context) Cookie(n string) (string, error) {
 	s, err := c.request.Cookie(n)
 	if err != nil {
@@ -588,20 +688,21 @@ func (c *Context) QueryAll() (map[string]string, error) {
 	return r, nil
 }
 
-// Get the IP off the requester
+// Get the IP off the requester, X-Forwarded-For is only used behind a trusted proxy, see App.SetTrustedProxies
 func (c *Context) RequestAddress() (string, error) {
-	h := c.request.Header.Get("X-Forwarded-For")
-	if h != "" {
-		a := strings.Split(h, ",")
-		return strings.TrimSpace(a[0]), nil
+	if c.fromTrustedProxy() {
+		ip, ok := c.forwardedFor()
+		if ok {
+			return ip.String(), nil
+		}
 	}
 
-	a := strings.Split(c.request.RemoteAddr, ":")
-	if a[0] == "" {
+	ip, ok := remoteIP(c.request.RemoteAddr)
+	if !ok {
 		return "", errs.HTMLError.Error
 	}
 
-	return a[0], nil
+	return ip.String(), nil
 }
 
 // Is the request secure?
@@ -609,8 +710,13 @@ func (c *Context) Secure() bool {
 	return c.Protocol() == "https"
 }
 
-// Get the protocol used by the request
+// Get the protocol used by the request, X-Forwarded-Proto is only used behind a trusted proxy, see App.SetTrustedProxies
 func (c *Context) Protocol() string {
+	switch p := strings.ToLower(c.forwardedHeader("X-Forwarded-Proto")); p {
+	case "http", "https":
+		return p
+	}
+
 	if c.request.TLS == nil {
 		return "http"
 	}
@@ -618,8 +724,14 @@ func (c *Context) Protocol() string {
 	return "https"
 }
 
-// Using the provided Binder, binds the context body with the a any
+// Using the provided Binder, binds the context body with the a any.
+// Multipart forms are parsed with the MultipartMemory of the App.
 func (c *Context) ShouldBindWith(a any, b binding.Binder) error {
+	m, ok := b.(binding.MultipartBinder)
+	if ok {
+		b = m.WithMaxMemory(c.multipartMemory())
+	}
+
 	return b.Bind(c.request, a)
 }
 
@@ -627,13 +739,34 @@ func (c *Context) ShouldBindWith(a any, b binding.Binder) error {
 func (c *Context) MustBindWith(a any, b binding.Binder) error {
 	err := c.ShouldBindWith(a, b)
 	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		c.AbortWithError(bindStatus(err), err)
 		return err
 	}
 
 	return nil
 }
 
+// Using the provided BindingBody, binds the cached body with the a any.
+// Use this when the body needs to be bound more than once.
+func (c *Context) ShouldBindBodyWith(a any, b binding.BindingBody) error {
+	body, err := c.Body()
+	if err != nil {
+		return err
+	}
+
+	return b.BindBody(body, a)
+}
+
+// Get the status to respond with when binding fails
+func bindStatus(err error) int {
+	var m *http.MaxBytesError
+	if errors.As(err, &m) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
 // Bind JSON to a any
 func (c *Context) BindJSON(a any) error {
 	return c.MustBindWith(a, binding.JSON)
@@ -673,3 +806,111 @@ func (c *Context) BindYAML(a any) error {
 func (c *Context) ShouldBindYAML(a any) error {
 	return c.ShouldBindWith(a, binding.YAML)
 }
+
+// Bind MessagePack to a any
+func (c *Context) BindMsgPack(a any) error {
+	return c.MustBindWith(a, binding.MsgPack)
+}
+
+// Wrapper for ShouldBindWith(a, binding.MsgPack)
+func (c *Context) ShouldBindMsgPack(a any) error {
+	return c.ShouldBindWith(a, binding.MsgPack)
+}
+
+// Bind CBOR to a any
+func (c *Context) BindCBOR(a any) error {
+	return c.MustBindWith(a, binding.CBOR)
+}
+
+// Wrapper for ShouldBindWith(a, binding.CBOR)
+func (c *Context) ShouldBindCBOR(a any) error {
+	return c.ShouldBindWith(a, binding.CBOR)
+}
+
+// Bind Protocol Buffers to a any, a must be a proto.Message
+func (c *Context) BindProtoBuf(a any) error {
+	return c.MustBindWith(a, binding.ProtoBuf)
+}
+
+// Wrapper for ShouldBindWith(a, binding.ProtoBuf)
+func (c *Context) ShouldBindProtoBuf(a any) error {
+	return c.ShouldBindWith(a, binding.ProtoBuf)
+}
+
+// Bind the query of the request to a any
+func (c *Context) BindQuery(a any) error {
+	return c.MustBindWith(a, binding.Query)
+}
+
+// Wrapper for ShouldBindWith(a, binding.Query)
+func (c *Context) ShouldBindQuery(a any) error {
+	return c.ShouldBindWith(a, binding.Query)
+}
+
+// Bind a urlencoded or multipart form to a any
+func (c *Context) BindForm(a any) error {
+	return c.MustBindWith(a, binding.Form)
+}
+
+// Wrapper for ShouldBindWith(a, binding.Form)
+func (c *Context) ShouldBindForm(a any) error {
+	return c.ShouldBindWith(a, binding.Form)
+}
+
+// Bind the headers of the request to a any
+func (c *Context) BindHeader(a any) error {
+	return c.MustBindWith(a, binding.Header)
+}
+
+// Wrapper for ShouldBindWith(a, binding.Header)
+func (c *Context) ShouldBindHeader(a any) error {
+	return c.ShouldBindWith(a, binding.Header)
+}
+
+// Bind the path params to a any
+func (c *Context) BindURI(a any) error {
+	err := c.ShouldBindURI(a)
+	if err != nil {
+		c.AbortWithError(bindStatus(err), err)
+		return err
+	}
+
+	return nil
+}
+
+// Using binding.URI, binds the path params with the a any
+func (c *Context) ShouldBindURI(a any) error {
+	return binding.URI.BindURI(c.paramValues(), a)
+}
+
+// Get the params as a map of values
+func (c *Context) paramValues() map[string][]string {
+	m := make(map[string][]string, len(c.params))
+	for k, v := range c.params {
+		m[k] = []string{v}
+	}
+
+	return m
+}
+
+// Bind to a any, choosing the Binder from the method and Content-Type of the request.
+// Aborts with a 415 if there is no Binder for the Content-Type.
+func (c *Context) Bind(a any) error {
+	b, err := binding.Default(c.request.Method, c.GetHeader("Content-Type"))
+	if err != nil {
+		c.AbortWithError(http.StatusUnsupportedMediaType, err)
+		return err
+	}
+
+	return c.MustBindWith(a, b)
+}
+
+// Bind to a any, choosing the Binder from the method and Content-Type of the request
+func (c *Context) ShouldBind(a any) error {
+	b, err := binding.Default(c.request.Method, c.GetHeader("Content-Type"))
+	if err != nil {
+		return err
+	}
+
+	return c.ShouldBindWith(a, b)
+}
@@ -1,9 +1,18 @@
 package router
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/joseph-beck/routey/pkg/binding"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 var MockRoute = Route{
@@ -157,11 +166,18 @@ func TestContextAbortWithStatus(t *testing.T) {
 }
 
 func TestContextAbortWithError(t *testing.T) {
-	// c := Context{}
+	app := New()
+	app.SetErrorHandler(func(c *Context, s int, err error) {
+		c.String(s, err.Error())
+	})
 
-	// c.AbortWithError(http.StatusBadRequest, errors.New("error"))
-	// a := c.Aborted()
-	// assert.True(t, a)
+	w := httptest.NewRecorder()
+	c := Context{app: app, writer: w}
+	c.AbortWithError(http.StatusBadRequest, errors.New("error"))
+	assert.True(t, c.Aborted())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Body.String(), "only the status is written")
+	assert.Empty(t, w.Header().Get("Content-Type"))
 }
 
 func TestContextStatus(t *testing.T) {
@@ -265,7 +281,22 @@ func TestContextQueryAll(t *testing.T) {
 }
 
 func TestContextRequestAddress(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Forwarded-For", "10.0.0.1")
+	c := Context{app: New(), request: r}
+	a, err := c.RequestAddress()
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.1", a, "X-Forwarded-For is ignored without a trusted proxy")
 
+	r.RemoteAddr = "[2001:db8::1]:1234"
+	a, err = c.RequestAddress()
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8::1", a)
+
+	r.RemoteAddr = ""
+	_, err = c.RequestAddress()
+	assert.Error(t, err)
 }
 
 func TestContextSecure(t *testing.T) {
@@ -273,7 +304,14 @@ func TestContextSecure(t *testing.T) {
 }
 
 func TestContextProtocol(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	c := Context{app: New(), request: r}
+	assert.Equal(t, "http", c.Protocol(), "X-Forwarded-Proto is ignored without a trusted proxy")
 
+	assert.NoError(t, c.app.SetTrustedProxies("192.0.2.0/24"))
+	assert.Equal(t, "https", c.Protocol())
+	assert.True(t, c.Secure())
 }
 
 func TextContextShouldBindWith(t *testing.T) {
@@ -315,3 +353,189 @@ func TextContextShouldBindYAML(t *testing.T) {
 func TestContextBindYAML(t *testing.T) {
 
 }
+
+func TestContextShouldBindQuery(t *testing.T) {
+	type query struct {
+		Page int `query:"page"`
+	}
+
+	c := Context{request: httptest.NewRequest(http.MethodGet, "/?page=3", nil)}
+	var q query
+	err := c.ShouldBindQuery(&q)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, q.Page)
+}
+
+func TestContextShouldBindURI(t *testing.T) {
+	type uri struct {
+		ID int `uri:"id"`
+	}
+
+	c := Context{params: map[string]string{"id": "7"}}
+	var u uri
+	err := c.ShouldBindURI(&u)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, u.ID)
+}
+
+func TestContextShouldBindHeader(t *testing.T) {
+	type header struct {
+		Token string `header:"X-Token"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Token", "abc")
+	c := Context{request: r}
+	var h header
+	err := c.ShouldBindHeader(&h)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", h.Token)
+}
+
+func TestContextShouldBind(t *testing.T) {
+	type body struct {
+		Name string `json:"name" query:"name"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"routey"}`))
+	r.Header.Set("Content-Type", "application/json")
+	c := Context{request: r}
+	var b body
+	err := c.ShouldBind(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", b.Name)
+
+	c = Context{request: httptest.NewRequest(http.MethodGet, "/?name=query", nil)}
+	err = c.ShouldBind(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "query", b.Name)
+}
+
+func TestContextBind(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/unknown")
+	w := httptest.NewRecorder()
+	c := Context{request: r, writer: w}
+	err := c.Bind(&struct{}{})
+	assert.Error(t, err)
+	assert.True(t, c.Aborted())
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestContextShouldBindBodyWith(t *testing.T) {
+	type body struct {
+		Name string `json:"name"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"routey"}`))
+	c := Context{request: r}
+	for i := 0; i < 2; i++ {
+		var b body
+		err := c.ShouldBindBodyWith(&b, binding.JSON)
+		assert.NoError(t, err)
+		assert.Equal(t, "routey", b.Name)
+	}
+}
+
+func TestContextMsgPack(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w}
+	c.MsgPack(http.StatusOK, M{"name": "routey"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+	var m map[string]string
+	err := msgpack.Unmarshal(w.Body.Bytes(), &m)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", m["name"])
+}
+
+func TestContextCBOR(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w}
+	c.CBOR(http.StatusOK, M{"name": "routey"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/cbor", w.Header().Get("Content-Type"))
+
+	var m map[string]string
+	err := cbor.Unmarshal(w.Body.Bytes(), &m)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", m["name"])
+}
+
+func TestContextProtoBuf(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w}
+	c.ProtoBuf(http.StatusOK, wrapperspb.String("routey"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+
+	m := &wrapperspb.StringValue{}
+	err := proto.Unmarshal(w.Body.Bytes(), m)
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", m.GetValue())
+}
+
+func TestContextRenderWith(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w}
+	c.RenderWith(http.StatusCreated, JSON{Data: M{"name": "routey"}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusCreated, c.status)
+	assert.JSONEq(t, `{"name":"routey"}`, w.Body.String())
+}
+
+func TestContextRenderWithError(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w}
+	c.JSON(http.StatusOK, make(chan int))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, c.Aborted())
+
+	w = httptest.NewRecorder()
+	c = Context{writer: w}
+	c.RenderWith(http.StatusOK, failingRenderer{write: true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+	assert.True(t, c.Aborted())
+}
+
+func TestContextRenderWithErrorContentType(t *testing.T) {
+	ct := ""
+	app := New()
+	app.SetErrorHandler(func(c *Context, s int, err error) {
+		ct = c.writer.Header().Get("Content-Type")
+	})
+
+	w := httptest.NewRecorder()
+	c := Context{app: app, writer: w}
+	c.Header("Content-Type", "application/problem+json")
+	c.JSON(http.StatusOK, make(chan int))
+	assert.Equal(t, "application/problem+json", ct, "the Content-Type set by the handler is kept")
+
+	w = httptest.NewRecorder()
+	c = Context{app: app, writer: w}
+	c.JSON(http.StatusOK, make(chan int))
+	assert.Empty(t, ct, "the Content-Type written by the Renderer is removed")
+}
+
+func TestContextHandleError(t *testing.T) {
+	app := New()
+	app.SetErrorHandler(func(c *Context, s int, err error) {
+		c.JSON(s, M{"error": err.Error()})
+	})
+
+	w := httptest.NewRecorder()
+	c := Context{app: app, writer: w}
+	c.RenderWith(http.StatusOK, failingRenderer{})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"failed"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	c = Context{app: app, writer: w}
+	c.HandleError(http.StatusForbidden, errors.New("forbidden"))
+	assert.True(t, c.Aborted())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"forbidden"}`, w.Body.String())
+}
@@ -0,0 +1,300 @@
+package router
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// A signed or encrypted cookie was changed, or was not made with any of the keys
+	ErrCookieTampered = errors.New("cookie has been tampered with")
+	// No keys were set with App.SetCookieKeys
+	ErrNoCookieKeys = errors.New("no cookie keys")
+	// A signed or encrypted cookie is older than the max age set with App.SetCookieMaxAge
+	ErrCookieExpired = errors.New("cookie has expired")
+)
+
+// How long signed and encrypted cookies are accepted for by default
+const DefaultCookieMaxAge = 30 * 24 * time.Hour
+
+// Set the keys used to sign and encrypt cookies. New cookies use the first key,
+// and cookies made with any of the keys are accepted, so keys can be rotated by adding a new first key.
+// Keys should be at least 32 random bytes.
+func (a *App) SetCookieKeys(keys ...[]byte) {
+	a.cookieKeys = keys
+}
+
+// Set how long signed and encrypted cookies are accepted for after they are set, defaults to DefaultCookieMaxAge.
+// The time they were set is signed or encrypted with the value, so an old value can not be sent again once it is too old.
+// A max age of 0 accepts cookies of any age.
+func (a *App) SetCookieMaxAge(d time.Duration) {
+	a.cookieMaxAge = d
+}
+
+// Create a cookie with secure defaults, HttpOnly with SameSite=Lax and a Path of /
+func NewCookie(n string, v string) *http.Cookie {
+	return &http.Cookie{
+		Name:     n,
+		Value:    v,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Set a cookie, Secure when the request is secure, with SameSite=Lax and a Path of / unless they are set.
+// HttpOnly is kept as it is set, use NewCookie for a cookie that scripts can not read.
+// The value is escaped, and unescaped again by Context.Cookie.
+func (c *Context) SetCookie(ck *http.Cookie) {
+	k := *ck
+	c.writeCookie(&k)
+}
+
+// Set a cookie that can be read by scripts, even when ck.HttpOnly is set.
+// Used for cookies such as a CSRF token read by JavaScript.
+func (c *Context) SetScriptCookie(ck *http.Cookie) {
+	k := *ck
+	k.HttpOnly = false
+	c.writeCookie(&k)
+}
+
+// Delete a cookie, pass the cookie it was set with when it was set with a Path other than / or a Domain
+func (c *Context) DeleteCookie(n string, ck ...*http.Cookie) {
+	k := &http.Cookie{
+		Name:    n,
+		Value:   "",
+		MaxAge:  -1,
+		Expires: time.Unix(0, 0),
+	}
+	if len(ck) > 0 && ck[0] != nil {
+		k.Path = ck[0].Path
+		k.Domain = ck[0].Domain
+	}
+
+	c.SetCookie(k)
+}
+
+// Set a cookie signed with HMAC, it can be read but not changed by the client
+func (c *Context) SetSignedCookie(ck *http.Cookie) error {
+	v, err := c.cookieCodec().sign(ck.Name, ck.Value, time.Now())
+	if err != nil {
+		return err
+	}
+
+	k := *ck
+	k.Value = v
+	c.SetCookie(&k)
+	return nil
+}
+
+// Get a cookie set with SetSignedCookie, returns ErrCookieTampered if it has been changed and ErrCookieExpired if it is too old
+func (c *Context) SignedCookie(n string) (string, error) {
+	ck, err := c.request.Cookie(n)
+	if err != nil {
+		return "", err
+	}
+
+	return c.cookieCodec().verify(n, ck.Value)
+}
+
+// Set a cookie encrypted with AES-GCM, it can not be read or changed by the client
+func (c *Context) SetEncryptedCookie(ck *http.Cookie) error {
+	v, err := c.cookieCodec().encrypt(ck.Name, ck.Value, time.Now())
+	if err != nil {
+		return err
+	}
+
+	k := *ck
+	k.Value = v
+	c.SetCookie(&k)
+	return nil
+}
+
+// Get a cookie set with SetEncryptedCookie, returns ErrCookieTampered if it has been changed and ErrCookieExpired if it is too old
+func (c *Context) EncryptedCookie(n string) (string, error) {
+	ck, err := c.request.Cookie(n)
+	if err != nil {
+		return "", err
+	}
+
+	return c.cookieCodec().decrypt(n, ck.Value)
+}
+
+// Write the cookie with the secure defaults, escaping the value
+func (c *Context) writeCookie(ck *http.Cookie) {
+	ck.Value = url.QueryEscape(ck.Value)
+	if ck.Path == "" {
+		ck.Path = "/"
+	}
+	if c.Secure() {
+		ck.Secure = true
+	}
+	if ck.SameSite == 0 || ck.SameSite == http.SameSiteDefaultMode {
+		ck.SameSite = http.SameSiteLaxMode
+	}
+
+	http.SetCookie(c.writer, ck)
+}
+
+// Get the codec for the keys of the App
+func (c *Context) cookieCodec() cookieCodec {
+	if c.app == nil {
+		return cookieCodec{}
+	}
+
+	return cookieCodec{keys: c.app.cookieKeys, maxAge: c.app.cookieMaxAge}
+}
+
+// Signs and encrypts cookie values, the name of the cookie is authenticated with the value
+// so a value can not be moved to another cookie.
+// Values carry the time they were made, and are rejected once they are older than maxAge, unless it is 0.
+type cookieCodec struct {
+	keys   [][]byte
+	maxAge time.Duration
+}
+
+// Sign the value made at t, as base64(time + value).base64(mac)
+func (k cookieCodec) sign(n string, v string, t time.Time) (string, error) {
+	if len(k.keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+
+	p := cookiePayload(v, t)
+	m := cookieMAC(k.keys[0], n, string(p))
+	return base64.RawURLEncoding.EncodeToString(p) + "." + base64.RawURLEncoding.EncodeToString(m), nil
+}
+
+// Verify a signed value with each of the keys
+func (k cookieCodec) verify(n string, s string) (string, error) {
+	if len(k.keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+
+	a, b, ok := strings.Cut(s, ".")
+	if !ok {
+		return "", ErrCookieTampered
+	}
+
+	p, err := base64.RawURLEncoding.DecodeString(a)
+	if err != nil {
+		return "", ErrCookieTampered
+	}
+	m, err := base64.RawURLEncoding.DecodeString(b)
+	if err != nil {
+		return "", ErrCookieTampered
+	}
+
+	for _, key := range k.keys {
+		if hmac.Equal(m, cookieMAC(key, n, string(p))) {
+			return k.open(p)
+		}
+	}
+
+	return "", ErrCookieTampered
+}
+
+// Encrypt the value made at t, as base64(nonce + ciphertext of time + value)
+func (k cookieCodec) encrypt(n string, v string, t time.Time) (string, error) {
+	if len(k.keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+
+	g, err := cookieAEAD(k.keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, g.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	b := g.Seal(nonce, nonce, cookiePayload(v, t), []byte(n))
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Decrypt a value with each of the keys
+func (k cookieCodec) decrypt(n string, s string) (string, error) {
+	if len(k.keys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return "", ErrCookieTampered
+	}
+
+	for _, key := range k.keys {
+		g, err := cookieAEAD(key)
+		if err != nil {
+			return "", err
+		}
+		if len(b) < g.NonceSize() {
+			return "", ErrCookieTampered
+		}
+
+		p, err := g.Open(nil, b[:g.NonceSize()], b[g.NonceSize():], []byte(n))
+		if err == nil {
+			return k.open(p)
+		}
+	}
+
+	return "", ErrCookieTampered
+}
+
+// Get the value of an authenticated payload, checking it is not older than maxAge
+func (k cookieCodec) open(p []byte) (string, error) {
+	if len(p) < 8 {
+		return "", ErrCookieTampered
+	}
+
+	t := time.Unix(int64(binary.BigEndian.Uint64(p)), 0)
+	if k.maxAge > 0 && time.Since(t) > k.maxAge {
+		return "", ErrCookieExpired
+	}
+
+	return string(p[8:]), nil
+}
+
+// Prefix the value with the unix time it was made at
+func cookiePayload(v string, t time.Time) []byte {
+	p := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(v)), uint64(t.Unix()))
+	return append(p, v...)
+}
+
+// Get the MAC of a cookie
+func cookieMAC(key []byte, n string, v string) []byte {
+	h := hmac.New(sha256.New, deriveCookieKey(key, "sign"))
+	h.Write([]byte(n))
+	h.Write([]byte{0})
+	h.Write([]byte(v))
+	return h.Sum(nil)
+}
+
+// Get the AES-GCM cipher of a key
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(deriveCookieKey(key, "encrypt"))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(b)
+}
+
+// Derive a separate 32 byte key for each use of a key
+func deriveCookieKey(key []byte, use string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("routey cookie " + use))
+	return h.Sum(nil)
+}
@@ -0,0 +1,196 @@
+package router
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cookieContext(app *App, ck ...*http.Cookie) (*Context, *httptest.ResponseRecorder) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, k := range ck {
+		r.AddCookie(k)
+	}
+	w := httptest.NewRecorder()
+
+	return &Context{app: app, writer: w, request: r}, w
+}
+
+func TestContextSetCookie(t *testing.T) {
+	c, w := cookieContext(New())
+	c.SetCookie(NewCookie("name", "routey"))
+
+	ck := w.Result().Cookies()[0]
+	assert.Equal(t, "routey", ck.Value)
+	assert.Equal(t, "/", ck.Path)
+	assert.True(t, ck.HttpOnly)
+	assert.False(t, ck.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, ck.SameSite)
+
+	c, w = cookieContext(New())
+	c.SetCookie(&http.Cookie{Name: "name", Value: "routey"})
+
+	ck = w.Result().Cookies()[0]
+	assert.Equal(t, "/", ck.Path)
+	assert.False(t, ck.HttpOnly, "HttpOnly is kept as it is set")
+	assert.Equal(t, http.SameSiteLaxMode, ck.SameSite)
+
+	c, w = cookieContext(New())
+	c.request.TLS = &tls.ConnectionState{}
+	c.SetScriptCookie(&http.Cookie{Name: "token", Value: "t", Path: "/app", SameSite: http.SameSiteStrictMode})
+
+	ck = w.Result().Cookies()[0]
+	assert.Equal(t, "/app", ck.Path)
+	assert.False(t, ck.HttpOnly)
+	assert.True(t, ck.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, ck.SameSite)
+}
+
+func TestContextSetCookieEscape(t *testing.T) {
+	v := "a value; with=symbols,%"
+	c, w := cookieContext(New())
+	c.SetCookie(NewCookie("name", v))
+
+	ck := w.Result().Cookies()[0]
+	assert.NotEqual(t, v, ck.Value)
+
+	c, _ = cookieContext(New(), ck)
+	r, err := c.Cookie("name")
+	assert.NoError(t, err)
+	assert.Equal(t, v, r)
+}
+
+func TestContextDeleteCookie(t *testing.T) {
+	c, w := cookieContext(New())
+	c.DeleteCookie("name")
+
+	ck := w.Result().Cookies()[0]
+	assert.Equal(t, "name", ck.Name)
+	assert.Empty(t, ck.Value)
+	assert.Equal(t, -1, ck.MaxAge)
+	assert.Equal(t, "/", ck.Path)
+
+	c, w = cookieContext(New())
+	c.DeleteCookie("name", &http.Cookie{Path: "/app", Domain: "example.com"})
+
+	ck = w.Result().Cookies()[0]
+	assert.Equal(t, "name", ck.Name)
+	assert.Equal(t, -1, ck.MaxAge)
+	assert.Equal(t, "/app", ck.Path)
+	assert.Equal(t, "example.com", ck.Domain)
+}
+
+func TestContextSignedCookie(t *testing.T) {
+	app := New()
+	app.SetCookieKeys([]byte("old-key"))
+
+	c, w := cookieContext(app)
+	assert.NoError(t, c.SetSignedCookie(&http.Cookie{Name: "user", Value: "routey"}))
+	ck := w.Result().Cookies()[0]
+
+	c, _ = cookieContext(app, ck)
+	v, err := c.SignedCookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", v)
+
+	app.SetCookieKeys([]byte("new-key"), []byte("old-key"))
+	c, _ = cookieContext(app, ck)
+	v, err = c.SignedCookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", v)
+
+	a, m, _ := strings.Cut(ck.Value, ".")
+	p, _ := base64.RawURLEncoding.DecodeString(a)
+	p = append(p[:len(p)-len("routey")], "admin"...)
+	c, _ = cookieContext(app, &http.Cookie{Name: "user", Value: base64.RawURLEncoding.EncodeToString(p) + "." + m})
+	_, err = c.SignedCookie("user")
+	assert.ErrorIs(t, err, ErrCookieTampered)
+
+	c, _ = cookieContext(app, &http.Cookie{Name: "admin", Value: ck.Value})
+	_, err = c.SignedCookie("admin")
+	assert.ErrorIs(t, err, ErrCookieTampered)
+
+	app.SetCookieKeys([]byte("new-key"))
+	c, _ = cookieContext(app, ck)
+	_, err = c.SignedCookie("user")
+	assert.ErrorIs(t, err, ErrCookieTampered)
+
+	c, _ = cookieContext(app)
+	_, err = c.SignedCookie("user")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+
+	c, _ = cookieContext(New())
+	assert.ErrorIs(t, c.SetSignedCookie(&http.Cookie{Name: "user"}), ErrNoCookieKeys)
+}
+
+func TestContextEncryptedCookie(t *testing.T) {
+	app := New()
+	app.SetCookieKeys([]byte("old-key"))
+
+	c, w := cookieContext(app)
+	assert.NoError(t, c.SetEncryptedCookie(NewCookie("user", "routey")))
+	ck := w.Result().Cookies()[0]
+	assert.NotContains(t, ck.Value, "routey")
+	assert.True(t, ck.HttpOnly)
+
+	app.SetCookieKeys([]byte("new-key"), []byte("old-key"))
+	c, _ = cookieContext(app, ck)
+	v, err := c.EncryptedCookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", v)
+
+	b := []byte(ck.Value)
+	b[len(b)-2] ^= 1
+	c, _ = cookieContext(app, &http.Cookie{Name: "user", Value: string(b)})
+	_, err = c.EncryptedCookie("user")
+	assert.ErrorIs(t, err, ErrCookieTampered)
+
+	c, _ = cookieContext(app, &http.Cookie{Name: "other", Value: ck.Value})
+	_, err = c.EncryptedCookie("other")
+	assert.ErrorIs(t, err, ErrCookieTampered)
+
+	c, _ = cookieContext(app, &http.Cookie{Name: "user", Value: "AA"})
+	_, err = c.EncryptedCookie("user")
+	assert.ErrorIs(t, err, ErrCookieTampered)
+}
+
+func TestContextCookieMaxAge(t *testing.T) {
+	app := New()
+	app.SetCookieKeys([]byte("key"))
+	assert.Equal(t, DefaultCookieMaxAge, app.cookieMaxAge)
+
+	k := cookieCodec{keys: app.cookieKeys}
+	old := time.Now().Add(-DefaultCookieMaxAge - time.Hour)
+	s, err := k.sign("user", "routey", old)
+	assert.NoError(t, err)
+	e, err := k.encrypt("user", "routey", old)
+	assert.NoError(t, err)
+
+	c, _ := cookieContext(app, &http.Cookie{Name: "user", Value: s})
+	_, err = c.SignedCookie("user")
+	assert.ErrorIs(t, err, ErrCookieExpired)
+
+	c, _ = cookieContext(app, &http.Cookie{Name: "user", Value: e})
+	_, err = c.EncryptedCookie("user")
+	assert.ErrorIs(t, err, ErrCookieExpired)
+
+	app.SetCookieMaxAge(0)
+	c, _ = cookieContext(app, &http.Cookie{Name: "user", Value: s})
+	v, err := c.SignedCookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", v)
+
+	app.SetCookieMaxAge(time.Hour)
+	s, err = k.sign("user", "routey", time.Now().Add(-30*time.Minute))
+	assert.NoError(t, err)
+	c, _ = cookieContext(app, &http.Cookie{Name: "user", Value: s})
+	v, err = c.SignedCookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "routey", v)
+}
@@ -0,0 +1,334 @@
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	// The CSRF token of an unsafe request was missing or did not match
+	ErrCSRFToken = errors.New("csrf token is missing or invalid")
+	// An unsafe request came from another origin
+	ErrCSRFOrigin = errors.New("csrf request from another origin")
+)
+
+// Length of a CSRF secret in bytes
+const csrfSecretLength = 32
+
+// Configure the CSRF decorator
+//
+//   - Field: the form field of the token, defaults to csrf_token
+//
+//   - Header: the header of the token, defaults to X-CSRF-Token
+//
+//   - Cookie: the cookie of the token when there is no Session, defaults to _csrf
+//
+//   - Origin: the public origin of the App, such as https://example.com, defaults to the origin of the request.
+//     Behind a proxy that terminates TLS set Origin, or trust the proxy with App.SetTrustedProxies so X-Forwarded-Proto and X-Forwarded-Host are used
+//
+//   - TrustedOrigins: other origins allowed to make requests, such as https://admin.example.com
+//
+//   - ExemptPaths: paths that are not checked, a path ending in / exempts every path below it
+//
+//   - Exempt: requests that are not checked, such as a group of routes
+//
+//   - ErrorHandler: responds when a request is rejected, defaults to aborting with 403 Forbidden
+type CSRFConfig struct {
+	Field          string
+	Header         string
+	Cookie         string
+	Origin         string
+	TrustedOrigins []string
+	ExemptPaths    []string
+	Exempt         func(c *Context) bool
+	ErrorHandler   ErrorHandlerFunc
+}
+
+// The CSRF token of a request
+type csrfState struct {
+	cfg    *CSRFConfig
+	secret []byte
+}
+
+// Protect unsafe requests against cross-site request forgery.
+// The token is kept in the Session when the Sessions decorator is used before this one, the synchronizer token pattern,
+// otherwise in a cookie that has to be sent back with the token, the double-submit cookie pattern.
+// Requests other than GET, HEAD, OPTIONS and TRACE must come from the same origin, or a trusted one,
+// and send the token in the form field or header.
+func CSRF(cfg ...CSRFConfig) DecoratorFunc {
+	o := CSRFConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+	if o.Field == "" {
+		o.Field = "csrf_token"
+	}
+	if o.Header == "" {
+		o.Header = "X-CSRF-Token"
+	}
+	if o.Cookie == "" {
+		o.Cookie = "_csrf"
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = func(c *Context, s int, err error) {
+			c.HandleError(s, err)
+		}
+	}
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			s := &csrfState{cfg: &o}
+			s.secret = s.load(c)
+
+			p := c.csrf
+			c.csrf = s
+			defer func() {
+				c.csrf = p
+			}()
+
+			if csrfSafe(c.request.Method) || o.exempt(c) {
+				f(c)
+				return
+			}
+
+			err := o.check(c, s.secret)
+			if err != nil {
+				logWarn(c.logger(), err.Error(), "CSRF")
+				o.ErrorHandler(c, http.StatusForbidden, err)
+				return
+			}
+
+			f(c)
+		}
+	}
+}
+
+// Get a CSRF token for the request, empty when the CSRF decorator is not used.
+// Every call gives a different token for the same secret, so the token can not be guessed from a compressed page.
+func (c *Context) CSRFToken() string {
+	if c.csrf == nil {
+		return ""
+	}
+
+	return maskCSRF(c.csrf.ensure(c))
+}
+
+// Get a hidden form field with the CSRF token of the request
+func (c *Context) CSRFField() template.HTML {
+	if c.csrf == nil {
+		return ""
+	}
+
+	return csrfInput(c.csrf.cfg.Field, c.CSRFToken())
+}
+
+// Template function for a hidden form field with a CSRF token, given the Context so the configured Field is used
+func csrfField(c *Context) template.HTML {
+	if c == nil {
+		return ""
+	}
+
+	return c.CSRFField()
+}
+
+// A hidden form field
+func csrfInput(n string, v string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(n) + `" value="` + template.HTMLEscapeString(v) + `">`)
+}
+
+// Get the secret from the Session or the cookie
+func (s *csrfState) load(c *Context) []byte {
+	var v string
+	if c.Session() != nil {
+		t, _ := c.Session().Get(s.cfg.Cookie)
+		v, _ = t.(string)
+	} else {
+		ck, err := c.request.Cookie(s.cfg.Cookie)
+		if err == nil {
+			v = ck.Value
+		}
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil || len(b) != csrfSecretLength {
+		return nil
+	}
+	return b
+}
+
+// Get the secret, creating and keeping one when the request has none
+func (s *csrfState) ensure(c *Context) []byte {
+	if s.secret != nil {
+		return s.secret
+	}
+
+	s.secret = make([]byte, csrfSecretLength)
+	_, err := rand.Read(s.secret)
+	if err != nil {
+		panic(err)
+	}
+
+	v := base64.RawURLEncoding.EncodeToString(s.secret)
+	if c.Session() != nil {
+		c.Session().Set(s.cfg.Cookie, v)
+	} else {
+		c.SetScriptCookie(&http.Cookie{
+			Name:  s.cfg.Cookie,
+			Value: v,
+		})
+	}
+
+	return s.secret
+}
+
+// Is the request exempt from being checked
+func (o *CSRFConfig) exempt(c *Context) bool {
+	if c.route != nil && c.route.CSRFExempt {
+		return true
+	}
+
+	p := c.request.URL.Path
+	for _, e := range o.ExemptPaths {
+		if p == e || (strings.HasSuffix(e, "/") && strings.HasPrefix(p, e)) {
+			return true
+		}
+	}
+
+	return o.Exempt != nil && o.Exempt(c)
+}
+
+// Check the origin and token of an unsafe request
+func (o *CSRFConfig) check(c *Context, secret []byte) error {
+	if !o.sameOrigin(c) {
+		return ErrCSRFOrigin
+	}
+	if secret == nil {
+		return ErrCSRFToken
+	}
+
+	t := c.request.Header.Get(o.Header)
+	if t == "" {
+		t = o.formToken(c)
+	}
+	if !validCSRF(t, secret) {
+		return ErrCSRFToken
+	}
+
+	return nil
+}
+
+// Does the request come from the same origin, or a trusted one.
+// The Referer is used when there is no Origin, and a request with neither is left to the token.
+func (o *CSRFConfig) sameOrigin(c *Context) bool {
+	h := c.request.Header.Get("Origin")
+	if h == "" || h == "null" {
+		u, err := url.Parse(c.request.Header.Get("Referer"))
+		if err == nil && u.Host != "" {
+			h = u.Scheme + "://" + u.Host
+		}
+	}
+
+	if slices.Contains(o.TrustedOrigins, h) {
+		return true
+	}
+
+	switch c.request.Header.Get("Sec-Fetch-Site") {
+	case "cross-site", "same-site":
+		return false
+	}
+
+	switch h {
+	case "":
+		return true
+	case "null":
+		return false
+	}
+	return strings.EqualFold(h, o.origin(c))
+}
+
+// Get the origin of the App, the configured Origin or the origin of the request
+func (o *CSRFConfig) origin(c *Context) string {
+	if o.Origin != "" {
+		return strings.TrimSuffix(o.Origin, "/")
+	}
+
+	h := c.forwardedHeader("X-Forwarded-Host")
+	if h == "" {
+		h = c.request.Host
+	}
+	return c.Protocol() + "://" + h
+}
+
+// Get the token from the form, only parsing url encoded and multipart bodies
+func (o *CSRFConfig) formToken(c *Context) string {
+	m, _, _ := mime.ParseMediaType(c.request.Header.Get("Content-Type"))
+	switch m {
+	case "application/x-www-form-urlencoded":
+		err := c.request.ParseForm()
+		if err != nil {
+			return ""
+		}
+	case "multipart/form-data":
+		err := c.request.ParseMultipartForm(c.multipartMemory())
+		if err != nil {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	return c.request.PostForm.Get(o.Field)
+}
+
+// Is the method safe, so it does not need to be checked
+func csrfSafe(m string) bool {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// Mask the secret with a random pad, as base64(pad + pad^secret)
+func maskCSRF(secret []byte) string {
+	b := make([]byte, 2*len(secret))
+	_, err := rand.Read(b[:len(secret)])
+	if err != nil {
+		panic(err)
+	}
+
+	for i := range secret {
+		b[len(secret)+i] = b[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Does the token match the secret, either masked or the value of the cookie
+func validCSRF(t string, secret []byte) bool {
+	b, err := base64.RawURLEncoding.DecodeString(t)
+	if err != nil {
+		return false
+	}
+
+	switch len(b) {
+	case len(secret):
+		return hmac.Equal(b, secret)
+	case 2 * len(secret):
+		u := make([]byte, len(secret))
+		for i := range u {
+			u[i] = b[i] ^ b[len(secret)+i]
+		}
+		return hmac.Equal(u, secret)
+	}
+
+	return false
+}
@@ -0,0 +1,216 @@
+package router
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The headers of a form post
+var csrfForm = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+
+func TestCSRFDoubleSubmit(t *testing.T) {
+	app := New()
+	app.Decorate(CSRF(CSRFConfig{ExemptPaths: []string{"/api/"}}))
+	app.Get("/form", "", func(c *Context) {
+		c.String(http.StatusOK, c.CSRFToken())
+	})
+	app.Post("/form", "", func(c *Context) {
+		c.String(http.StatusOK, "posted")
+	})
+	app.Post("/api/hook", "", func(c *Context) {
+		c.String(http.StatusOK, "hooked")
+	})
+	app.Route(Route{
+		Path:        "/exempt",
+		Method:      Post,
+		HandlerFunc: func(c *Context) { c.String(http.StatusOK, "exempt") },
+		CSRFExempt:  true,
+	})
+
+	w := serveRequest(app, http.MethodGet, "/form", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	token := w.Body.String()
+	ck := w.Result().Cookies()[0]
+	assert.Equal(t, "_csrf", ck.Name)
+	assert.False(t, ck.HttpOnly)
+	assert.NotEqual(t, ck.Value, token)
+
+	w = serveRequest(app, http.MethodGet, "/form", "", nil, ck)
+	assert.NotEqual(t, token, w.Body.String(), "tokens are masked")
+	assert.Empty(t, w.Result().Cookies())
+
+	w = serveRequest(app, http.MethodPost, "/form", "csrf_token="+url.QueryEscape(token), csrfForm, ck)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "posted", w.Body.String())
+
+	w = serveRequest(app, http.MethodPost, "/form", "", map[string]string{"X-CSRF-Token": ck.Value}, ck)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/form", "", map[string]string{"X-CSRF-Token": token}, ck)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/form", "", nil, ck)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/form", "csrf_token="+url.QueryEscape(token), csrfForm)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/form", "csrf_token=invalid", csrfForm, ck)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/api/hook", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/exempt", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCSRFSession(t *testing.T) {
+	app := New()
+	app.Decorate(Sessions(), CSRF())
+	app.Get("/form", "", func(c *Context) {
+		c.String(http.StatusOK, c.CSRFToken())
+	})
+	app.Post("/form", "", func(c *Context) {
+		c.String(http.StatusOK, "posted")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/form", "", nil)
+	token := w.Body.String()
+	cks := w.Result().Cookies()
+	assert.Len(t, cks, 1)
+	assert.Equal(t, "session", cks[0].Name)
+
+	w = serveRequest(app, http.MethodPost, "/form", "csrf_token="+url.QueryEscape(token), csrfForm, cks[0])
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveRequest(app, http.MethodPost, "/form", "csrf_token="+url.QueryEscape(token), csrfForm)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCSRFOrigin(t *testing.T) {
+	app := New()
+	app.Decorate(CSRF(CSRFConfig{TrustedOrigins: []string{"https://admin.example.com"}}))
+	app.Get("/form", "", func(c *Context) {
+		c.String(http.StatusOK, c.CSRFToken())
+	})
+	app.Post("/form", "", func(c *Context) {
+		c.String(http.StatusOK, "posted")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/form", "", nil)
+	token := w.Body.String()
+	ck := w.Result().Cookies()[0]
+
+	cases := []struct {
+		headers map[string]string
+		status  int
+	}{
+		{map[string]string{"Origin": "http://example.com"}, http.StatusOK},
+		{map[string]string{"Origin": "http://evil.com"}, http.StatusForbidden},
+		{map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{map[string]string{"Origin": "https://admin.example.com", "Sec-Fetch-Site": "same-site"}, http.StatusOK},
+		{map[string]string{"Referer": "http://example.com/form"}, http.StatusOK},
+		{map[string]string{"Referer": "http://evil.com/form"}, http.StatusForbidden},
+		{map[string]string{"Sec-Fetch-Site": "same-origin"}, http.StatusOK},
+		{map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{map[string]string{"Origin": "http://example.com", "Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		tc.headers["X-CSRF-Token"] = token
+		w = serveRequest(app, http.MethodPost, "/form", "", tc.headers, ck)
+		assert.Equal(t, tc.status, w.Code, tc.headers)
+	}
+}
+
+func TestCSRFBehindProxy(t *testing.T) {
+	cases := []struct {
+		cfg     CSRFConfig
+		proxy   bool
+		headers map[string]string
+		status  int
+	}{
+		{CSRFConfig{}, false, map[string]string{"Origin": "https://example.com"}, http.StatusForbidden},
+		{CSRFConfig{Origin: "https://example.com/"}, false, map[string]string{"Origin": "https://example.com"}, http.StatusOK},
+		{CSRFConfig{}, true, map[string]string{"Origin": "https://example.com", "X-Forwarded-Proto": "https"}, http.StatusOK},
+		{CSRFConfig{}, true, map[string]string{
+			"Origin":            "https://public.example.com",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "public.example.com",
+		}, http.StatusOK},
+	}
+
+	for i, tc := range cases {
+		app := New()
+		app.Decorate(CSRF(tc.cfg))
+		if tc.proxy {
+			assert.NoError(t, app.SetTrustedProxies("192.0.2.1"))
+		}
+		app.Get("/form", "", func(c *Context) {
+			c.String(http.StatusOK, c.CSRFToken())
+		})
+		app.Post("/form", "", func(c *Context) {
+			c.String(http.StatusOK, "posted")
+		})
+
+		w := serveRequest(app, http.MethodGet, "/form", "", nil)
+		tc.headers["X-CSRF-Token"] = w.Body.String()
+		w = serveRequest(app, http.MethodPost, "/form", "", tc.headers, w.Result().Cookies()[0])
+		assert.Equal(t, tc.status, w.Code, i)
+	}
+}
+
+func TestCSRFErrorHandler(t *testing.T) {
+	var e error
+	app := New()
+	app.Decorate(CSRF(CSRFConfig{
+		Exempt: func(c *Context) bool {
+			return c.Path() == "/api/hook"
+		},
+		ErrorHandler: func(c *Context, s int, err error) {
+			e = err
+			c.String(http.StatusTeapot, "rejected")
+		},
+	}))
+	app.Post("/form", "", func(c *Context) {
+		c.String(http.StatusOK, "posted")
+	})
+	app.Post("/api/hook", "", func(c *Context) {
+		c.String(http.StatusOK, "hooked")
+	})
+
+	w := serveRequest(app, http.MethodPost, "/form", "", nil)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.ErrorIs(t, e, ErrCSRFToken)
+
+	w = serveRequest(app, http.MethodPost, "/form", "", map[string]string{"Origin": "http://evil.com"})
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.ErrorIs(t, e, ErrCSRFOrigin)
+
+	w = serveRequest(app, http.MethodPost, "/api/hook", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCSRFField(t *testing.T) {
+	app := New()
+	app.Decorate(CSRF(CSRFConfig{Field: "_token"}))
+	app.SetHTMLTemplate(template.Must(template.New("form").Funcs(app.funcMap).Parse(`<form>{{ csrfField .ctx }}</form>`)))
+	app.Get("/form", "", func(c *Context) {
+		c.HTML(http.StatusOK, "form", map[string]any{"ctx": c})
+	})
+
+	w := serveRequest(app, http.MethodGet, "/form", "", nil)
+	assert.Regexp(t, `^<form><input type="hidden" name="_token" value="[\w-]{86}"></form>$`, w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 1)
+
+	assert.Empty(t, csrfField(nil))
+
+	c, _ := cookieContext(New())
+	assert.Empty(t, c.CSRFToken())
+	assert.Empty(t, c.CSRFField())
+}
@@ -0,0 +1,174 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Send a file as a download, the client saves it as the name.
+// Ranges and conditional requests are supported so downloads can be resumed.
+func (c *Context) FileAttachment(p string, name string) {
+	f, err := os.Open(p)
+	if err != nil {
+		c.HandleError(staticStatus(err), err)
+		return
+	}
+	defer f.Close()
+
+	i, err := f.Stat()
+	if err != nil || i.IsDir() {
+		c.HandleError(http.StatusNotFound, os.ErrNotExist)
+		return
+	}
+
+	if name == "" {
+		name = filepath.Base(p)
+	}
+	c.Header("Content-Disposition", contentDisposition("attachment", name))
+
+	c.serveContent(filepath.Base(p), i.ModTime(), f)
+}
+
+// Send the data read from the reader, with any extra headers such as Content-Disposition.
+// A length below 0 is unknown, and the body is streamed without a Content-Length.
+//
+// Ranges and If-Range are supported when the status is 200 and the length is known,
+// readers that can not seek only support a single range, skipping the data before it.
+func (c *Context) DataFromReader(s int, length int64, contentType string, r io.Reader, headers map[string]string) {
+	for k, v := range headers {
+		c.Header(k, v)
+	}
+	if contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+
+	if s == http.StatusOK && length >= 0 {
+		rs, ok := r.(io.ReadSeeker)
+		if !ok {
+			rs = &forwardSeeker{r: r, size: length}
+			if c.writer.Header().Get("Content-Type") == "" {
+				c.Header("Content-Type", "application/octet-stream")
+			}
+			if strings.Contains(c.request.Header.Get("Range"), ",") {
+				c.request.Header.Del("Range")
+			}
+		}
+
+		t, _ := http.ParseTime(c.writer.Header().Get("Last-Modified"))
+
+		c.serveContent("", t, io.NewSectionReader(readerAt{rs}, 0, length))
+		return
+	}
+
+	if length >= 0 {
+		c.Header("Content-Length", fmt.Sprint(length))
+		r = io.LimitReader(r, length)
+	}
+
+	c.Status(s)
+	_, err := io.Copy(c.writer, r)
+	if err != nil {
+		logError(c.logger(), err.Error(), "RENDER")
+		c.Abort()
+	}
+}
+
+// Get a RFC 6266 Content-Disposition with a quoted ASCII filename,
+// and a RFC 5987 encoded filename* when the name is not plain ASCII
+func contentDisposition(t string, name string) string {
+	var a, e strings.Builder
+	plain := true
+
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			plain = false
+			continue
+		case r > 0x7f:
+			plain = false
+			a.WriteByte('_')
+		case r == '"' || r == '\\':
+			a.WriteByte('\\')
+			a.WriteRune(r)
+		default:
+			a.WriteRune(r)
+		}
+	}
+
+	if plain {
+		return fmt.Sprintf(`%s; filename="%s"`, t, a.String())
+	}
+
+	for _, b := range []byte(name) {
+		if isAttrChar(b) {
+			e.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&e, "%%%02X", b)
+	}
+
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, t, a.String(), e.String())
+}
+
+// Is the byte a RFC 5987 attr-char, which does not need percent encoding?
+func isAttrChar(b byte) bool {
+	switch {
+	case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		return true
+	}
+
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
+
+// Lets a reader that can only move forward be used as an io.ReadSeeker,
+// seeking forward skips the data, seeking backward fails
+type forwardSeeker struct {
+	r    io.Reader
+	pos  int64
+	size int64
+}
+
+// Read from the current position
+func (f *forwardSeeker) Read(b []byte) (int, error) {
+	n, err := f.r.Read(b)
+	f.pos += int64(n)
+	return n, err
+}
+
+// Seek forward by skipping the data
+func (f *forwardSeeker) Seek(o int64, w int) (int64, error) {
+	switch w {
+	case io.SeekCurrent:
+		o += f.pos
+	case io.SeekEnd:
+		o += f.size
+	}
+
+	if o < f.pos {
+		return f.pos, errors.New("unable to seek backward")
+	}
+
+	n, err := io.CopyN(io.Discard, f.r, o-f.pos)
+	f.pos += n
+	return f.pos, err
+}
+
+// Reads at an offset by seeking, used with io.NewSectionReader so the size is never seeked to
+type readerAt struct {
+	rs io.ReadSeeker
+}
+
+// Seek to the offset, then read
+func (r readerAt) ReadAt(b []byte, o int64) (int, error) {
+	_, err := r.rs.Seek(o, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	return io.ReadFull(r.rs, b)
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextFileAttachment(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "report.txt")
+	assert.NoError(t, os.WriteFile(p, []byte("0123456789"), 0o644))
+
+	app := New()
+	app.Get("/download", "", func(c *Context) {
+		c.FileAttachment(p, "résumé 2024.txt")
+	})
+	app.Get("/missing", "", func(c *Context) {
+		c.FileAttachment(filepath.Join(t.TempDir(), "missing.txt"), "missing.txt")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/download", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Equal(t, `attachment; filename="r_sum_ 2024.txt"; filename*=UTF-8''r%C3%A9sum%C3%A9%202024.txt`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+
+	w = serveRequest(app, http.MethodGet, "/download", "", map[string]string{"Range": "bytes=2-4"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "234", w.Body.String())
+	assert.Equal(t, "bytes 2-4/10", w.Header().Get("Content-Range"))
+
+	m := w.Header().Get("Last-Modified")
+	w = serveRequest(app, http.MethodGet, "/download", "", map[string]string{"Range": "bytes=2-4", "If-Range": m})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+
+	w = serveRequest(app, http.MethodGet, "/download", "", map[string]string{"Range": "bytes=2-4", "If-Range": "Mon, 02 Jan 2006 15:04:05 GMT"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestContextDataFromReader(t *testing.T) {
+	app := New()
+	app.Get("/seeker", "", func(c *Context) {
+		c.DataFromReader(http.StatusOK, 10, "text/plain", strings.NewReader("0123456789"), map[string]string{
+			"Content-Disposition": contentDisposition("attachment", "data.txt"),
+			"ETag":                `"v1"`,
+		})
+	})
+	app.Get("/reader", "", func(c *Context) {
+		c.DataFromReader(http.StatusOK, 10, "", onlyReader{strings.NewReader("0123456789")}, nil)
+	})
+	app.Get("/unknown", "", func(c *Context) {
+		c.DataFromReader(http.StatusAccepted, -1, "text/plain", strings.NewReader("0123456789"), nil)
+	})
+
+	w := serveRequest(app, http.MethodGet, "/seeker", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+	assert.Equal(t, `attachment; filename="data.txt"`, w.Header().Get("Content-Disposition"))
+
+	w = serveRequest(app, http.MethodGet, "/seeker", "", map[string]string{"Range": "bytes=-3", "If-Range": `"v1"`})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "789", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/seeker", "", map[string]string{"Range": "bytes=-3", "If-Range": `"v0"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/reader", "", map[string]string{"Range": "bytes=5-"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "56789", w.Body.String())
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+
+	w = serveRequest(app, http.MethodGet, "/reader", "", map[string]string{"Range": "bytes=0-1,5-6"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/reader", "", map[string]string{"Range": "bytes=20-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+
+	w = serveRequest(app, http.MethodGet, "/unknown", "", map[string]string{"Range": "bytes=5-"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Length"))
+}
+
+func TestContextServeContentStatus(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "report.txt")
+	assert.NoError(t, os.WriteFile(p, []byte("0123456789"), 0o644))
+
+	status := 0
+	app := New()
+	app.Decorate(func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			f(c)
+			status = c.status
+		}
+	})
+	app.Get("/download", "", func(c *Context) {
+		c.FileAttachment(p, "report.txt")
+	})
+	app.Get("/reader", "", func(c *Context) {
+		c.DataFromReader(http.StatusOK, 10, "text/plain", strings.NewReader("0123456789"), nil)
+	})
+
+	cases := []struct {
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"/download", nil, http.StatusOK},
+		{"/download", map[string]string{"Range": "bytes=2-4"}, http.StatusPartialContent},
+		{"/download", map[string]string{"Range": "bytes=20-30"}, http.StatusRequestedRangeNotSatisfiable},
+		{"/download", map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}, http.StatusNotModified},
+		{"/reader", map[string]string{"Range": "bytes=0-1"}, http.StatusPartialContent},
+	}
+
+	for _, tc := range cases {
+		w := serveRequest(app, http.MethodGet, tc.path, "", tc.headers)
+		assert.Equal(t, tc.status, w.Code, tc.headers)
+		assert.Equal(t, tc.status, status, tc.headers)
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `attachment; filename="a.txt"`, contentDisposition("attachment", "a.txt"))
+	assert.Equal(t, `inline; filename="say \"hi\".txt"`, contentDisposition("inline", `say "hi".txt`))
+	assert.Equal(t, `attachment; filename="ab"; filename*=UTF-8''a%0Ab`, contentDisposition("attachment", "a\nb"))
+}
+
+type onlyReader struct {
+	r *strings.Reader
+}
+
+func (o onlyReader) Read(b []byte) (int, error) {
+	return o.r.Read(b)
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Options given to a TemplateEngine when it is loaded
+//
+//   - Delims: the delimiters of the App
+//
+//   - FuncMap: the functions of the App, such as asset
+type TemplateOptions struct {
+	Delims  HTMLDelims
+	FuncMap template.FuncMap
+}
+
+// A template engine renders pages through Context.HTML, such as text/template, Components or Markdown
+type TemplateEngine interface {
+	HTMLRenderer
+	Load(o TemplateOptions) error
+}
+
+// Text renderer, renders a text/template
+//
+//   - Stream: write the text as it renders, flushing at each {{flush}}, otherwise it is buffered
+type Text struct {
+	Template    *texttemplate.Template
+	Name        string
+	Data        any
+	ContentType string
+	Stream      bool
+}
+
+// Template engine for text/template, used for plain text such as emails.
+// Pages are rendered inside layouts in the same way as HTMLLayouts.
+//
+//   - FS: the templates, rendered by their name relative to the root such as emails/welcome.txt
+//
+//   - Config: the layouts and partials, the Extension defaults to .txt
+//
+//   - ContentType: the content type of the pages, defaults to text/plain
+type TextTemplates struct {
+	FS          fs.FS
+	Config      HTMLLayoutConfig
+	ContentType string
+
+	pages map[string]layoutPage[*texttemplate.Template]
+	mu    sync.RWMutex
+}
+
+// Set the TemplateEngine used by Context.HTML, loading it with the delimiters and functions of the App
+func (a *App) SetTemplateEngine(e TemplateEngine) {
+	err := e.Load(TemplateOptions{
+		Delims:  a.htmlDelims,
+		FuncMap: a.funcMap,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	a.setHTMLRender(e)
+}
+
+// Render the text
+func (t Text) Render(w http.ResponseWriter) error {
+	return renderTemplate(w, t, t.Stream, func(o io.Writer) error {
+		if t.Name == "" {
+			return t.Template.Execute(o, t.Data)
+		}
+		return t.Template.ExecuteTemplate(o, t.Name, t.Data)
+	})
+}
+
+// Write the content type
+func (t Text) WriteContentType(w http.ResponseWriter) {
+	if t.ContentType == "" {
+		writeContentType(w, plainContentType)
+		return
+	}
+
+	writeContentType(w, []string{t.ContentType})
+}
+
+// Parse every page with its layout and the partials
+func (t *TextTemplates) Load(o TemplateOptions) error {
+	c := t.Config
+	if c.Extension == "" {
+		c.Extension = ".txt"
+	}
+
+	base := texttemplate.New("").Delims(o.Delims.Left, o.Delims.Right).Funcs(texttemplate.FuncMap(o.FuncMap))
+	p, err := parseLayouts(t.FS, c, base)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.pages = p
+	t.mu.Unlock()
+
+	return nil
+}
+
+// Create an instance of the HTMLRenderer, for the page
+func (t *TextTemplates) Instance(n string, d any) Renderer {
+	t.mu.RLock()
+	p, ok := t.pages[n]
+	t.mu.RUnlock()
+	if !ok {
+		return htmlError{err: templateNotDefined(n)}
+	}
+
+	return Text{
+		Template:    p.template,
+		Name:        p.entry,
+		Data:        d,
+		ContentType: t.ContentType,
+	}
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextTemplates(t *testing.T) {
+	e := &TextTemplates{
+		FS: fstest.MapFS{
+			"layouts/email.txt":  {Data: []byte(`{{block "body" .}}{{end}}-- {{template "partials/sign.txt"}}`)},
+			"partials/sign.txt":  {Data: []byte(`{{upper "routey"}}`)},
+			"emails/welcome.txt": {Data: []byte(`{{define "body"}}Hello <{{.}}>{{end}}`)},
+		},
+		Config: HTMLLayoutConfig{Layout: "layouts/email.txt"},
+	}
+
+	app := New()
+	app.funcMap["upper"] = strings.ToUpper
+	app.SetTemplateEngine(e)
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/pages/emails/welcome.txt", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Hello <routey>-- ROUTEY", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = serveRequest(app, http.MethodGet, "/pages/emails/missing.txt", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestSetTemplateEngine(t *testing.T) {
+	app := New()
+	assert.Panics(t, func() {
+		app.SetTemplateEngine(&TextTemplates{FS: fstest.MapFS{
+			"index.txt": {Data: []byte(`{{if}}`)},
+		}})
+	})
+
+	app.SetTemplateEngine(&TextTemplates{
+		FS:          fstest.MapFS{"index.csv": {Data: []byte(`a,{{.}}`)}},
+		Config:      HTMLLayoutConfig{Extension: ".csv"},
+		ContentType: "text/csv",
+	})
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+	w := serveRequest(app, http.MethodGet, "/pages/index.csv", "", nil)
+	assert.Equal(t, "a,routey", w.Body.String())
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+}
@@ -13,6 +13,19 @@ func (f HandlerFunc) Serve(c *Context) {
 // Decorate a HandlerFunc
 type DecoratorFunc func(f HandlerFunc) HandlerFunc
 
+// Chain decorators into one, the first decorator is the outermost
+func Chain(d ...DecoratorFunc) DecoratorFunc {
+	return func(f HandlerFunc) HandlerFunc {
+		for i := len(d) - 1; i >= 0; i-- {
+			if d[i] != nil {
+				f = d[i](f)
+			}
+		}
+
+		return f
+	}
+}
+
 // Wrap a standard http Handler in a routey HandlerFunc
 func Wrap(f http.HandlerFunc) HandlerFunc {
 	return func(c *Context) {
@@ -28,5 +41,13 @@ func (m MiddlewareFunc) Serve(c *Context) {
 	m(c)
 }
 
+// Handles an error that occurred while serving a request, responding with the status
+type ErrorHandlerFunc func(c *Context, s int, err error)
+
+// Responds with the status and its text
+func defaultErrorHandler(c *Context, s int, err error) {
+	c.String(s, http.StatusText(s))
+}
+
 // Func for shutting down the router
 type ShutdownFunc func()
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// Serve a request to the App with the headers and cookies, nil cookies are skipped, recording the response
+func serveRequest(app *App, method string, target string, body string, headers map[string]string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	var b io.Reader
+	if body != "" {
+		b = strings.NewReader(body)
+	}
+
+	r := httptest.NewRequest(method, target, b)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	for _, c := range cookies {
+		if c != nil {
+			r.AddCookie(c)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	return w
+}
+
+// Get the cookie the response set, nil when it set none
+func responseCookie(w *httptest.ResponseRecorder, n string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == n {
+			return c
+		}
+	}
+
+	return nil
+}
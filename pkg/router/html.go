@@ -1,7 +1,10 @@
 package router
 
 import (
+	"bytes"
 	"html/template"
+	"io"
+	"io/fs"
 	"net/http"
 )
 
@@ -16,11 +19,27 @@ type HTMLDelims struct {
 	Right string
 }
 
+// How HTML templates are written to the response
+type HTMLMode int
+
+const (
+	// Render the whole page before writing it, so an error responds with 500 through the error handler
+	HTMLBuffered HTMLMode = iota
+	// Write the page as it renders, {{flush}} sends what has been rendered so far to the client
+	HTMLStreamed
+)
+
+// Written by {{flush}}, replaced with a flush of the response when streaming
+const htmlFlushMarker = "\x00routey:flush\x00"
+
 // Default HTML renderer.
+//
+//   - Stream: write the page as it renders, flushing at each {{flush}}, otherwise it is buffered
 type HTML struct {
 	Template *template.Template
 	Name     string
 	Data     any
+	Stream   bool
 }
 
 // HTML Renderer implementor
@@ -30,21 +49,26 @@ type HTMLRender struct {
 }
 
 // Debug HTML renderer, allows for editing of HTML files at runtime.
+// Templates are read from the Files, the Glob, or the Patterns of the FS, and parsed again on every render.
+//
+// Deprecated: load templates with the App in debug mode, they are watched and only parsed again when they change.
 type HTMLDebug struct {
-	Files   []string
-	Glob    string
-	Delims  HTMLDelims
-	FuncMap template.FuncMap
+	Files    []string
+	Glob     string
+	FS       fs.FS
+	Patterns []string
+	Delims   HTMLDelims
+	FuncMap  template.FuncMap
 }
 
 // Render the HTML
 func (h HTML) Render(w http.ResponseWriter) error {
-	h.WriteContentType(w)
-
-	if h.Name == "" {
-		return h.Template.Execute(w, h.Data)
-	}
-	return h.Template.ExecuteTemplate(w, h.Name, h.Data)
+	return renderTemplate(w, h, h.Stream, func(o io.Writer) error {
+		if h.Name == "" {
+			return h.Template.Execute(o, h.Data)
+		}
+		return h.Template.ExecuteTemplate(o, h.Name, h.Data)
+	})
 }
 
 // Write the content type
@@ -77,6 +101,10 @@ func (h HTMLDebug) Instance(n string, d any) Renderer {
 		t = template.Must(template.New("").Delims(h.Delims.Left, h.Delims.Right).Funcs(h.FuncMap).ParseGlob(h.Glob))
 		l = true
 	}
+	if h.FS != nil {
+		t = template.Must(template.New("").Delims(h.Delims.Left, h.Delims.Right).Funcs(h.FuncMap).ParseFS(h.FS, h.Patterns...))
+		l = true
+	}
 	if !l {
 		panic("tried to create an empty HTML render")
 	}
@@ -87,3 +115,63 @@ func (h HTMLDebug) Instance(n string, d any) Renderer {
 		Data:     d,
 	}
 }
+
+// Set how HTML templates are written to the response, HTMLBuffered by default
+func (a *App) SetHTMLMode(m HTMLMode) {
+	a.htmlMode = m
+}
+
+// Marks where a streamed template is flushed, used as {{flush}}
+func htmlFlush() template.HTML {
+	return template.HTML(htmlFlushMarker)
+}
+
+// Execute a template into a buffer before writing it, or stream it flushing at each {{flush}}
+func renderTemplate(w http.ResponseWriter, r Renderer, stream bool, exec func(io.Writer) error) error {
+	if stream {
+		r.WriteContentType(w)
+		return exec(flushWriter{w: w})
+	}
+
+	b := &bytes.Buffer{}
+	err := exec(flushWriter{w: b})
+	if err != nil {
+		return err
+	}
+
+	return writeRendered(w, r, b.Bytes())
+}
+
+// Removes the marks written by {{flush}}, flushing the writer in their place if it can be flushed
+type flushWriter struct {
+	w io.Writer
+}
+
+// Write the data, flushing at each mark
+func (f flushWriter) Write(b []byte) (int, error) {
+	n := 0
+
+	for {
+		i := bytes.Index(b, []byte(htmlFlushMarker))
+		if i < 0 {
+			m, err := f.w.Write(b)
+			return n + m, err
+		}
+
+		if i > 0 {
+			m, err := f.w.Write(b[:i])
+			n += m
+			if err != nil {
+				return n, err
+			}
+		}
+
+		r, ok := f.w.(http.ResponseWriter)
+		if ok {
+			http.NewResponseController(r).Flush()
+		}
+
+		n += len(htmlFlushMarker)
+		b = b[i+len(htmlFlushMarker):]
+	}
+}
@@ -1 +1,144 @@
 package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var htmlMockFS = fstest.MapFS{
+	"web/index.html": {Data: []byte(`{{define "index.html"}}embedded {{.}}{{end}}`)},
+	"web/about.txt":  {Data: []byte(`about`)},
+}
+
+func TestLoadHTMLFS(t *testing.T) {
+	for _, reload := range []bool{false, true} {
+		app := New(Config{Reload: reload})
+		app.LoadHTMLFS(htmlMockFS, "web/*.html")
+		defer app.setHTMLRender(nil)
+		app.Get("/pages", "/*page", func(c *Context) {
+			p, _ := c.Param("page")
+			c.HTML(http.StatusOK, p, "routey")
+		})
+
+		w := serveRequest(app, http.MethodGet, "/pages/index.html", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "embedded routey", w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	}
+
+	app := New(Config{Debug: false})
+	assert.Panics(t, func() {
+		app.LoadHTMLFS(htmlMockFS, "missing/*.html")
+	})
+}
+
+func TestLoadHTMLEmbed(t *testing.T) {
+	d := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(d, "web"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(d, "web", "index.html"), []byte(`{{define "index.html"}}disk {{.}}{{end}}`), 0o644))
+
+	app := New(Config{Debug: false})
+	app.LoadHTMLEmbed(htmlMockFS, d, "web/*.html")
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+	assert.Equal(t, "embedded routey", serveRequest(app, http.MethodGet, "/pages/index.html", "", nil).Body.String())
+
+	for _, cfg := range []Config{{Debug: true}, {Reload: true}} {
+		assert.NoError(t, os.WriteFile(filepath.Join(d, "web", "index.html"), []byte(`{{define "index.html"}}disk {{.}}{{end}}`), 0o644))
+
+		cfg.ReloadInterval = 5 * time.Millisecond
+		app = New(cfg)
+		app.LoadHTMLEmbed(htmlMockFS, d, "web/*.html")
+		defer app.setHTMLRender(nil)
+		app.Get("/pages", "/*page", func(c *Context) {
+			p, _ := c.Param("page")
+			c.HTML(http.StatusOK, p, "routey")
+		})
+		assert.Equal(t, "disk routey", serveRequest(app, http.MethodGet, "/pages/index.html", "", nil).Body.String(), cfg)
+
+		assert.NoError(t, os.WriteFile(filepath.Join(d, "web", "index.html"), []byte(`{{define "index.html"}}edited {{.}}{{end}}`), 0o644))
+		assert.Eventually(t, func() bool {
+			return serveRequest(app, http.MethodGet, "/pages/index.html", "", nil).Body.String() == "edited routey"
+		}, time.Second, 5*time.Millisecond, "an edit on disk is served")
+	}
+}
+
+func TestHTMLDebug(t *testing.T) {
+	d := t.TempDir()
+	p := filepath.Join(d, "index.html")
+	assert.NoError(t, os.WriteFile(p, []byte(`hello {{.}}`), 0o644))
+
+	h := HTMLDebug{Glob: filepath.Join(d, "*.html")}
+	w := httptest.NewRecorder()
+	assert.NoError(t, h.Instance("index.html", "routey").Render(w))
+	assert.Equal(t, "hello routey", w.Body.String())
+
+	assert.NoError(t, os.WriteFile(p, []byte(`goodbye {{.}}`), 0o644))
+	w = httptest.NewRecorder()
+	assert.NoError(t, h.Instance("index.html", "routey").Render(w))
+	assert.Equal(t, "goodbye routey", w.Body.String(), "templates are parsed on every render")
+
+	assert.Panics(t, func() {
+		HTMLDebug{}.Instance("index.html", nil)
+	})
+}
+
+func TestHTMLMode(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html":   {Data: []byte(`<head></head>{{flush}}<body>{{.}}</body>`)},
+		"broken.html": {Data: []byte(`<head></head>{{flush}}<body>{{.Missing.Field}}</body>`)},
+	}
+
+	app := New(Config{Debug: false})
+	app.LoadHTMLFS(fsys, "*.html")
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/pages/page.html", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<head></head><body>routey</body>", w.Body.String())
+	assert.False(t, w.Flushed)
+
+	w = serveRequest(app, http.MethodGet, "/pages/broken.html", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), w.Body.String())
+
+	app = New(Config{Debug: false})
+	app.LoadHTMLFS(fsys, "*.html")
+	app.SetHTMLMode(HTMLStreamed)
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+
+	w = serveRequest(app, http.MethodGet, "/pages/page.html", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<head></head><body>routey</body>", w.Body.String())
+	assert.True(t, w.Flushed)
+
+	w = serveRequest(app, http.MethodGet, "/pages/broken.html", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "<head></head><body>"))
+}
+
+func TestFlushWriter(t *testing.T) {
+	b := &strings.Builder{}
+	f := flushWriter{w: b}
+
+	n, err := f.Write([]byte("a" + htmlFlushMarker + "b" + htmlFlushMarker))
+	assert.NoError(t, err)
+	assert.Equal(t, 2+2*len(htmlFlushMarker), n)
+	assert.Equal(t, "ab", b.String())
+}
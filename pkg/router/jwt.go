@@ -0,0 +1,448 @@
+package router
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// The token is malformed, or its signature is not valid
+	ErrTokenInvalid = errors.New("token is invalid")
+	// The token has expired
+	ErrTokenExpired = errors.New("token has expired")
+	// The token has no exp, so it would never expire
+	ErrTokenNoExpiry = errors.New("token has no expiry")
+	// The token is not valid yet
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	// The token was issued by another issuer
+	ErrTokenIssuer = errors.New("token has an invalid issuer")
+	// The token is meant for another audience
+	ErrTokenAudience = errors.New("token has an invalid audience")
+)
+
+// Algorithms a JWT can be signed with
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// A key a JWT can be verified with
+//
+//   - ID: the kid of the key, tokens with a kid are only verified with the key of that ID
+//
+//   - Algorithm: the only algorithm the key can be used with, empty allows any algorithm of its type
+//
+//   - Key: a []byte for HS256, *rsa.PublicKey for RS256, *ecdsa.PublicKey for ES256 or ed25519.PublicKey for EdDSA
+type JWK struct {
+	ID        string
+	Algorithm string
+	Key       any
+}
+
+// A set of keys JWTs are verified with, the keys can be replaced while serving so they can be rotated
+type JWKS struct {
+	keys []JWK
+	mu   sync.RWMutex
+}
+
+// Configure the JWTAuth decorator
+//
+//   - Keys: the keys tokens are verified with
+//
+//   - Algorithms: the algorithms tokens can be signed with, defaults to HS256, RS256, ES256 and EdDSA
+//
+//   - Issuer: the iss tokens must have, empty does not check it
+//
+//   - Audience: the aud tokens must include, empty does not check it
+//
+//   - Leeway: allowed clock skew when checking exp and nbf
+//
+//   - AllowNoExpiry: accept tokens without an exp, by default they are rejected as they never expire
+//
+//   - Realm: the realm sent with WWW-Authenticate, defaults to Restricted
+//
+//   - Principal: creates the Principal from the claims, defaults to the sub and roles claims.
+//     A nil Principal rejects the token
+type JWTConfig struct {
+	Keys          *JWKS
+	Algorithms    []string
+	Issuer        string
+	Audience      string
+	Leeway        time.Duration
+	AllowNoExpiry bool
+	Realm         string
+	Principal     func(c *Context, claims map[string]any) (*Principal, error)
+}
+
+// A JWK as it is written in JSON
+type jwkJSON struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// The header of a JWT
+type jwtHeader struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid"`
+	Crit []string `json:"crit"`
+}
+
+// Authenticate requests with a JWT bearer token, responding 401 Unauthorized when it is missing or not valid
+func JWTAuth(cfg JWTConfig) DecoratorFunc {
+	if cfg.Realm == "" {
+		cfg.Realm = "Restricted"
+	}
+	if cfg.Principal == nil {
+		cfg.Principal = jwtPrincipal
+	}
+	challenge := `Bearer realm=` + strconv.Quote(cfg.Realm)
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			t := authorizationToken(c.request, "Bearer")
+			if t == "" {
+				c.Unauthorized(challenge, ErrNoCredentials)
+				return
+			}
+
+			claims, err := VerifyJWT(t, cfg)
+			if err != nil {
+				c.Unauthorized(challenge+`, error="invalid_token", error_description=`+strconv.Quote(err.Error()), err)
+				return
+			}
+
+			p, err := cfg.Principal(c, claims)
+			if err == nil && p == nil {
+				err = ErrInvalidCredentials
+			}
+			if err != nil {
+				c.Unauthorized(challenge+`, error="invalid_token", error_description=`+strconv.Quote(err.Error()), err)
+				return
+			}
+
+			p.Scheme = "Bearer"
+			if p.Claims == nil {
+				p.Claims = claims
+			}
+			c.SetPrincipal(p)
+
+			f(c)
+		}
+	}
+}
+
+// Verify the signature and claims of a JWT, returning its claims
+func VerifyJWT(token string, cfg JWTConfig) (map[string]any, error) {
+	algs := cfg.Algorithms
+	if algs == nil {
+		algs = []string{AlgHS256, AlgRS256, AlgES256, AlgEdDSA}
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenInvalid
+	}
+
+	h := jwtHeader{}
+	err := decodeJWTPart(parts[0], &h)
+	if err != nil || len(h.Crit) > 0 || !slices.Contains(algs, h.Alg) {
+		return nil, ErrTokenInvalid
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || cfg.Keys == nil || !cfg.Keys.verify(h, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrTokenInvalid
+	}
+
+	claims := make(map[string]any)
+	err = decodeJWTPart(parts[1], &claims)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+
+	err = checkJWTClaims(claims, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Create a JWKS from keys, such as a secret for HS256
+func NewJWKS(keys ...JWK) *JWKS {
+	return &JWKS{keys: keys}
+}
+
+// Parse a JWKS from JSON, as {"keys": [...]}
+func ParseJWKS(b []byte) (*JWKS, error) {
+	s := struct {
+		Keys []jwkJSON `json:"keys"`
+	}{}
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := make([]JWK, 0, len(s.Keys))
+	for _, j := range s.Keys {
+		k, err := j.key()
+		if err != nil {
+			return nil, err
+		}
+
+		ks = append(ks, JWK{ID: j.Kid, Algorithm: j.Alg, Key: k})
+	}
+
+	return NewJWKS(ks...), nil
+}
+
+// Load a JWKS from a JSON file
+func LoadJWKS(p string) (*JWKS, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(b)
+}
+
+// Replace the keys, used to rotate them
+func (s *JWKS) SetKeys(keys ...JWK) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = keys
+}
+
+// Verify the signature with the keys that can be used with the header
+func (s *JWKS) verify(h jwtHeader, input []byte, sig []byte) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if h.Kid != "" && k.ID != h.Kid {
+			continue
+		}
+		if k.Algorithm != "" && k.Algorithm != h.Alg {
+			continue
+		}
+
+		if verifyJWTSignature(h.Alg, k.Key, input, sig) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify a signature, the key must be of the type of the algorithm
+func verifyJWTSignature(alg string, key any, input []byte, sig []byte) bool {
+	d := sha256.Sum256(input)
+
+	switch alg {
+	case AlgHS256:
+		k, ok := key.([]byte)
+		if !ok || len(k) == 0 {
+			return false
+		}
+		m := hmac.New(sha256.New, k)
+		m.Write(input)
+		return hmac.Equal(sig, m.Sum(nil))
+	case AlgRS256:
+		k, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(k, crypto.SHA256, d[:], sig) == nil
+	case AlgES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k, d[:], r, s)
+	case AlgEdDSA:
+		k, ok := key.(ed25519.PublicKey)
+		return ok && len(k) == ed25519.PublicKeySize && ed25519.Verify(k, input, sig)
+	}
+
+	return false
+}
+
+// Check the exp, nbf, iss and aud claims
+func checkJWTClaims(claims map[string]any, cfg JWTConfig) error {
+	n := time.Now()
+
+	exp, ok, err := jwtTime(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !ok && !cfg.AllowNoExpiry {
+		return ErrTokenNoExpiry
+	}
+	if ok && n.After(exp.Add(cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	nbf, ok, err := jwtTime(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if ok && n.Add(cfg.Leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+
+	if cfg.Issuer != "" {
+		iss, _ := claims["iss"].(string)
+		if iss != cfg.Issuer {
+			return ErrTokenIssuer
+		}
+	}
+
+	if cfg.Audience != "" && !slices.Contains(jwtStrings(claims["aud"]), cfg.Audience) {
+		return ErrTokenAudience
+	}
+
+	return nil
+}
+
+// Get a NumericDate claim
+func jwtTime(claims map[string]any, n string) (time.Time, bool, error) {
+	v, ok := claims[n]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, ErrTokenInvalid
+	}
+
+	s := int64(f)
+	return time.Unix(s, int64((f-float64(s))*float64(time.Second))), true, nil
+}
+
+// Get a claim that is a string or a list of strings, such as aud
+func jwtStrings(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		s := make([]string, 0, len(t))
+		for _, e := range t {
+			e, ok := e.(string)
+			if ok {
+				s = append(s, e)
+			}
+		}
+		return s
+	}
+
+	return nil
+}
+
+// Create the Principal from the sub and roles claims
+func jwtPrincipal(c *Context, claims map[string]any) (*Principal, error) {
+	sub, _ := claims["sub"].(string)
+
+	return &Principal{
+		Subject: sub,
+		Roles:   jwtStrings(claims["roles"]),
+		Claims:  claims,
+	}, nil
+}
+
+// Decode a base64url JSON part of a JWT
+func decodeJWTPart(p string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return err
+	}
+
+	return json.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// Get the public key of a JWK
+func (j jwkJSON) key() (any, error) {
+	switch j.Kty {
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(j.K)
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk: invalid RSA exponent of %q", j.Kid)
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("jwk: unsupported curve %q of %q", j.Crv, j.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		k := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !k.Curve.IsOnCurve(k.X, k.Y) {
+			return nil, fmt.Errorf("jwk: point of %q is not on the curve", j.Kid)
+		}
+		return k, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk: unsupported curve %q of %q", j.Crv, j.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk: invalid Ed25519 key of %q", j.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("jwk: unsupported key type %q of %q", j.Kty, j.Kid)
+}
@@ -0,0 +1,193 @@
+package router
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signJWT(t *testing.T, alg string, kid string, key any, claims map[string]any) string {
+	h := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		h["kid"] = kid
+	}
+	hb, _ := json.Marshal(h)
+	cb, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb)
+	d := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case []byte:
+		m := hmac.New(sha256.New, k)
+		m.Write([]byte(input))
+		sig = m.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, d[:])
+	case *ecdsa.PrivateKey:
+		r, s, e := ecdsa.Sign(rand.Reader, k, d[:])
+		err = e
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(input))
+	}
+	assert.NoError(t, err)
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	secret := []byte("secret")
+	rk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ek, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, dk, _ := ed25519.GenerateKey(rand.Reader)
+
+	cfg := JWTConfig{
+		Keys: NewJWKS(
+			JWK{ID: "hs", Key: secret},
+			JWK{ID: "rs", Key: &rk.PublicKey},
+			JWK{ID: "es", Key: &ek.PublicKey},
+			JWK{ID: "ed", Key: dk.Public()},
+		),
+		Issuer:   "routey",
+		Audience: "api",
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := map[string]any{"sub": "user", "iss": "routey", "aud": []string{"api", "web"}, "exp": exp}
+
+	tokens := map[string]string{
+		AlgHS256: signJWT(t, AlgHS256, "hs", secret, claims),
+		AlgRS256: signJWT(t, AlgRS256, "rs", rk, claims),
+		AlgES256: signJWT(t, AlgES256, "", ek, claims),
+		AlgEdDSA: signJWT(t, AlgEdDSA, "ed", dk, claims),
+	}
+	for alg, tk := range tokens {
+		c, err := VerifyJWT(tk, cfg)
+		assert.NoError(t, err, alg)
+		assert.Equal(t, "user", c["sub"], alg)
+	}
+
+	cases := map[string]struct {
+		token string
+		err   error
+	}{
+		"wrong kid":      {signJWT(t, AlgHS256, "rs", secret, claims), ErrTokenInvalid},
+		"wrong key":      {signJWT(t, AlgHS256, "hs", []byte("other"), claims), ErrTokenInvalid},
+		"alg none":       {signJWT(t, "none", "", nil, claims), ErrTokenInvalid},
+		"rsa key as hs":  {signJWT(t, AlgHS256, "", []byte("x"), claims), ErrTokenInvalid},
+		"malformed":      {"a.b", ErrTokenInvalid},
+		"expired":        {signJWT(t, AlgHS256, "hs", secret, map[string]any{"iss": "routey", "aud": "api", "exp": time.Now().Add(-time.Minute).Unix()}), ErrTokenExpired},
+		"not valid yet":  {signJWT(t, AlgHS256, "hs", secret, map[string]any{"iss": "routey", "aud": "api", "exp": exp, "nbf": time.Now().Add(time.Minute).Unix()}), ErrTokenNotValidYet},
+		"wrong issuer":   {signJWT(t, AlgHS256, "hs", secret, map[string]any{"iss": "other", "aud": "api", "exp": exp}), ErrTokenIssuer},
+		"wrong audience": {signJWT(t, AlgHS256, "hs", secret, map[string]any{"iss": "routey", "aud": "web", "exp": exp}), ErrTokenAudience},
+		"invalid exp":    {signJWT(t, AlgHS256, "hs", secret, map[string]any{"iss": "routey", "aud": "api", "exp": "soon"}), ErrTokenInvalid},
+		"no exp":         {signJWT(t, AlgHS256, "hs", secret, map[string]any{"iss": "routey", "aud": "api"}), ErrTokenNoExpiry},
+	}
+	for n, tc := range cases {
+		_, err := VerifyJWT(tc.token, cfg)
+		assert.ErrorIs(t, err, tc.err, n)
+	}
+
+	cfg.Leeway = 2 * time.Minute
+	_, err := VerifyJWT(cases["expired"].token, cfg)
+	assert.NoError(t, err)
+
+	cfg.AllowNoExpiry = true
+	_, err = VerifyJWT(cases["no exp"].token, cfg)
+	assert.NoError(t, err)
+
+	cfg.Algorithms = []string{AlgRS256}
+	_, err = VerifyJWT(tokens[AlgHS256], cfg)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+
+	cfg.Keys.SetKeys(JWK{ID: "rs", Key: &rk.PublicKey, Algorithm: AlgRS256})
+	_, err = VerifyJWT(tokens[AlgRS256], cfg)
+	assert.NoError(t, err)
+}
+
+func TestLoadJWKS(t *testing.T) {
+	rk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ek, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	pub, dk, _ := ed25519.GenerateKey(rand.Reader)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	ecBytes := func(i interface{ FillBytes([]byte) []byte }) string {
+		return b64(i.FillBytes(make([]byte, 32)))
+	}
+	set := map[string]any{"keys": []map[string]any{
+		{"kty": "oct", "kid": "hs", "k": b64([]byte("secret"))},
+		{"kty": "RSA", "kid": "rs", "alg": "RS256", "n": b64(rk.N.Bytes()), "e": "AQAB"},
+		{"kty": "EC", "kid": "es", "crv": "P-256", "x": ecBytes(ek.X), "y": ecBytes(ek.Y)},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(pub)},
+	}}
+	b, _ := json.Marshal(set)
+	p := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(p, b, 0o600))
+
+	keys, err := LoadJWKS(p)
+	assert.NoError(t, err)
+
+	cfg := JWTConfig{Keys: keys, AllowNoExpiry: true}
+	for _, tk := range []string{
+		signJWT(t, AlgHS256, "hs", []byte("secret"), map[string]any{}),
+		signJWT(t, AlgRS256, "rs", rk, map[string]any{}),
+		signJWT(t, AlgES256, "es", ek, map[string]any{}),
+		signJWT(t, AlgEdDSA, "ed", dk, map[string]any{}),
+	} {
+		_, err = VerifyJWT(tk, cfg)
+		assert.NoError(t, err)
+	}
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	assert.Error(t, err)
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"unknown"}]}`))
+	assert.Error(t, err)
+	_, err = LoadJWKS(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestJWTAuth(t *testing.T) {
+	app := New()
+	app.Add(Get, "/auth", "", authHandler, JWTAuth(JWTConfig{Keys: NewJWKS(JWK{Key: []byte("secret")}), Realm: "api"}))
+	app.Add(Get, "/nil", "", authHandler, JWTAuth(JWTConfig{
+		Keys: NewJWKS(JWK{Key: []byte("secret")}),
+		Principal: func(c *Context, claims map[string]any) (*Principal, error) {
+			return nil, nil
+		},
+	}))
+
+	tk := signJWT(t, AlgHS256, "", []byte("secret"), map[string]any{"sub": "routey", "roles": []string{"admin"}, "exp": time.Now().Add(time.Hour).Unix()})
+	w := serveRequest(app, http.MethodGet, "/auth", "", map[string]string{"Authorization": "Bearer " + tk})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Bearer routey [admin] ", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/auth", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+
+	tk = signJWT(t, AlgHS256, "", []byte("secret"), map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})
+	w = serveRequest(app, http.MethodGet, "/auth", "", map[string]string{"Authorization": "Bearer " + tk})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="api", error="invalid_token", error_description="token has expired"`, w.Header().Get("WWW-Authenticate"))
+
+	tk = signJWT(t, AlgHS256, "", []byte("secret"), map[string]any{"exp": time.Now().Add(time.Hour).Unix()})
+	w = serveRequest(app, http.MethodGet, "/nil", "", map[string]string{"Authorization": "Bearer " + tk})
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "a nil Principal rejects the token")
+}
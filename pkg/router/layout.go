@@ -0,0 +1,261 @@
+package router
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Configure how pages are rendered inside layouts
+//
+//   - Layout: the default layout pages are rendered inside, empty renders pages on their own
+//
+//   - Layouts: the layout of a page, or of a directory of pages when the key ends in /, an empty layout renders pages on their own
+//
+//   - Partials: a directory of templates shared by every page and layout, defaults to partials
+//
+//   - Extension: the extension of template files, defaults to .html
+//
+// Names are relative to the root of the templates, such as layouts/base.html or admin/.
+type HTMLLayoutConfig struct {
+	Layout    string
+	Layouts   map[string]string
+	Partials  string
+	Extension string
+}
+
+// HTML Renderer that renders each page inside its layout.
+// Every page is parsed into its own template set with its layout and the partials,
+// so pages can override the blocks of a layout with their own define.
+//
+//   - FS: the templates, rendered by their name relative to the root such as users/index.html
+//
+//   - Config: the HTMLLayoutConfig
+type HTMLLayouts struct {
+	FS      fs.FS
+	Config  HTMLLayoutConfig
+	Delims  HTMLDelims
+	FuncMap template.FuncMap
+
+	pages map[string]htmlPage
+	mu    sync.RWMutex
+}
+
+// A page and the html/template set it is executed with
+type htmlPage = layoutPage[*template.Template]
+
+// Renders nothing, returning the error, so that it is handled by the error handler
+type htmlError struct {
+	err error
+}
+
+// Render pages inside layouts, loaded from a directory
+func (a *App) LoadHTMLLayouts(dir string, cfg ...HTMLLayoutConfig) {
+	a.LoadHTMLLayoutsFS(os.DirFS(dir), cfg...)
+}
+
+// Render pages inside layouts, loaded from a fs.FS such as an embed.FS.
+// In debug mode, or with Config.Reload, the templates are watched and parsed again when they change.
+func (a *App) LoadHTMLLayoutsFS(fsys fs.FS, cfg ...HTMLLayoutConfig) {
+	o := HTMLLayoutConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+
+	h := &HTMLLayouts{
+		FS:      fsys,
+		Config:  o,
+		Delims:  a.htmlDelims,
+		FuncMap: a.funcMap,
+	}
+
+	if a.reload {
+		a.reloadHTML(func() (HTMLRenderer, error) {
+			return h, h.Load()
+		}, fsTemplates(fsys))
+		return
+	}
+
+	err := h.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	a.setHTMLRender(h)
+}
+
+// Parse every page with its layout and the partials
+func (h *HTMLLayouts) Load() error {
+	p, err := h.parse()
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.pages = p
+	h.mu.Unlock()
+
+	return nil
+}
+
+// Create an instance of the HTMLRenderer, for the page
+func (h *HTMLLayouts) Instance(n string, d any) Renderer {
+	h.mu.RLock()
+	p, ok := h.pages[n]
+	h.mu.RUnlock()
+	if !ok {
+		return htmlError{err: templateNotDefined(n)}
+	}
+
+	return HTML{
+		Template: p.template,
+		Name:     p.entry,
+		Data:     d,
+	}
+}
+
+// Parse the templates into a template set per page
+func (h *HTMLLayouts) parse() (map[string]htmlPage, error) {
+	base := template.New("").Delims(h.Delims.Left, h.Delims.Right).Funcs(h.FuncMap)
+	return parseLayouts(h.FS, h.Config, base)
+}
+
+// A set of templates that pages and layouts can be parsed into, html/template or text/template
+type templateSet[T any] interface {
+	New(name string) T
+	Parse(text string) (T, error)
+	Clone() (T, error)
+}
+
+// A page and the template set it is executed with
+type layoutPage[T any] struct {
+	template T
+	entry    string
+}
+
+// Parse the templates of the fs.FS into a template set per page, cloned from the base with the partials
+func parseLayouts[T templateSet[T]](fsys fs.FS, o HTMLLayoutConfig, base T) (map[string]layoutPage[T], error) {
+	if o.Partials == "" {
+		o.Partials = "partials"
+	}
+	if o.Extension == "" {
+		o.Extension = ".html"
+	}
+	o.Partials = strings.TrimSuffix(o.Partials, "/") + "/"
+
+	layouts := map[string]bool{o.Layout: true}
+	for _, l := range o.Layouts {
+		layouts[l] = true
+	}
+
+	var partials, pages []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != o.Extension || layouts[p] {
+			return nil
+		}
+
+		if strings.HasPrefix(p, o.Partials) {
+			partials = append(partials, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range partials {
+		err = parseLayoutFile(fsys, base, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := make(map[string]layoutPage[T], len(pages))
+	for _, p := range pages {
+		t, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		l := o.layout(p)
+		if l != "" {
+			err = parseLayoutFile(fsys, t, l)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = parseLayoutFile(fsys, t, p)
+		if err != nil {
+			return nil, err
+		}
+
+		e := l
+		if e == "" {
+			e = p
+		}
+		r[p] = layoutPage[T]{template: t, entry: e}
+	}
+
+	return r, nil
+}
+
+// Parse a file into the set, named by its path
+func parseLayoutFile[T templateSet[T]](fsys fs.FS, t T, n string) error {
+	b, err := fs.ReadFile(fsys, n)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.New(n).Parse(string(b))
+	return err
+}
+
+// Get the layout of a page, an exact match wins over the longest matching directory
+func (o HTMLLayoutConfig) layout(p string) string {
+	l, ok := o.Layouts[p]
+	if ok {
+		return l
+	}
+
+	ds := make([]string, 0, len(o.Layouts))
+	for k := range o.Layouts {
+		if strings.HasSuffix(k, "/") && strings.HasPrefix(p, k) {
+			ds = append(ds, k)
+		}
+	}
+	if len(ds) == 0 {
+		return o.Layout
+	}
+
+	sort.Slice(ds, func(i, j int) bool {
+		return len(ds[i]) > len(ds[j])
+	})
+	return o.Layouts[ds[0]]
+}
+
+// The error for a page that is not defined
+func templateNotDefined(n string) error {
+	return fmt.Errorf("template: page %q is not defined", n)
+}
+
+// Return the error
+func (h htmlError) Render(w http.ResponseWriter) error {
+	return h.err
+}
+
+// Write the content type
+func (h htmlError) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var layoutMockFS = fstest.MapFS{
+	"layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}routey{{end}}</title>{{template "partials/nav.html" .}}<main>{{block "content" .}}empty{{end}}</main>`)},
+	"layouts/admin.html": {Data: []byte(`<admin>{{block "content" .}}{{end}}</admin>`)},
+	"partials/nav.html":  {Data: []byte(`<nav>{{upper "nav"}}</nav>`)},
+	"index.html":         {Data: []byte(`{{define "title"}}home{{end}}{{define "content"}}hello {{.}}{{end}}`)},
+	"about.html":         {Data: []byte(`{{define "content"}}about{{end}}`)},
+	"login.html":         {Data: []byte(`<login>{{template "partials/nav.html"}}</login>`)},
+	"admin/users.html":   {Data: []byte(`{{define "content"}}users{{end}}`)},
+	"notes.txt":          {Data: []byte(`not a template`)},
+}
+
+func TestHTMLLayouts(t *testing.T) {
+	for _, reload := range []bool{false, true} {
+		app := New(Config{Reload: reload})
+		app.funcMap["upper"] = strings.ToUpper
+		app.LoadHTMLLayoutsFS(layoutMockFS, HTMLLayoutConfig{
+			Layout: "layouts/base.html",
+			Layouts: map[string]string{
+				"admin/":     "layouts/admin.html",
+				"login.html": "",
+			},
+		})
+		defer app.setHTMLRender(nil)
+		app.Get("/pages", "/*page", func(c *Context) {
+			p, _ := c.Param("page")
+			c.HTML(http.StatusOK, p, "routey")
+		})
+
+		cases := map[string]string{
+			"/pages/index.html":       `<title>home</title><nav>NAV</nav><main>hello routey</main>`,
+			"/pages/about.html":       `<title>routey</title><nav>NAV</nav><main>about</main>`,
+			"/pages/login.html":       `<login><nav>NAV</nav></login>`,
+			"/pages/admin/users.html": `<admin>users</admin>`,
+		}
+
+		for p, e := range cases {
+			w := serveRequest(app, http.MethodGet, p, "", nil)
+			assert.Equal(t, http.StatusOK, w.Code, p)
+			assert.Equal(t, e, w.Body.String(), p)
+			assert.Contains(t, w.Header().Get("Content-Type"), "text/html", p)
+		}
+
+		w := serveRequest(app, http.MethodGet, "/pages/notes.txt", "", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestHTMLLayoutsReload(t *testing.T) {
+	d := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(d, "base.html"), []byte(`<main>{{block "content" .}}{{end}}</main>`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(d, "index.html"), []byte(`{{define "content"}}hello {{.}}{{end}}`), 0o644))
+
+	app := New(Config{Reload: true, ReloadInterval: 5 * time.Millisecond})
+	app.LoadHTMLLayouts(d, HTMLLayoutConfig{Layout: "base.html"})
+	defer app.setHTMLRender(nil)
+	app.Get("/page", "", func(c *Context) {
+		c.HTML(http.StatusOK, "index.html", "routey")
+	})
+	assert.Equal(t, "<main>hello routey</main>", serveRequest(app, http.MethodGet, "/page", "", nil).Body.String())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(d, "base.html"), []byte(`<body>{{block "content" .}}{{end}}</body>`), 0o644))
+	assert.Eventually(t, func() bool {
+		return serveRequest(app, http.MethodGet, "/page", "", nil).Body.String() == "<body>hello routey</body>"
+	}, time.Second, 5*time.Millisecond, "layouts are parsed again when they change")
+}
+
+func TestHTMLLayoutsInvalid(t *testing.T) {
+	h := &HTMLLayouts{FS: fstest.MapFS{
+		"index.html": {Data: []byte(`{{define "content"}}`)},
+	}}
+	assert.Error(t, h.Load())
+
+	h = &HTMLLayouts{
+		FS:     fstest.MapFS{"index.html": {Data: []byte(`index`)}},
+		Config: HTMLLayoutConfig{Layout: "layouts/missing.html"},
+	}
+	assert.Error(t, h.Load())
+}
+
+func TestHTMLLayoutConfigLayout(t *testing.T) {
+	o := HTMLLayoutConfig{
+		Layout: "base.html",
+		Layouts: map[string]string{
+			"admin/":           "admin.html",
+			"admin/settings/":  "settings.html",
+			"admin/login.html": "",
+		},
+	}
+
+	assert.Equal(t, "base.html", o.layout("index.html"))
+	assert.Equal(t, "admin.html", o.layout("admin/users.html"))
+	assert.Equal(t, "settings.html", o.layout("admin/settings/index.html"))
+	assert.Equal(t, "", o.layout("admin/login.html"))
+}
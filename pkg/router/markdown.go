@@ -0,0 +1,167 @@
+package router
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Template engine for Markdown pages, converted to HTML and rendered inside a layout.
+// Pages are executed as a text/template with the data and the FuncMap before they are converted,
+// raw HTML in a page is left out unless the Converter allows it.
+//
+//   - FS: the pages, rendered by their name relative to the root such as docs/intro.md
+//
+//   - Layout: an html/template in the FS that pages are rendered inside, empty renders pages on their own
+//
+//   - Extension: the extension of pages, defaults to .md
+//
+//   - Converter: converts Markdown to HTML, defaults to GitHub Flavored Markdown
+type Markdown struct {
+	FS        fs.FS
+	Layout    string
+	Extension string
+	Converter goldmark.Markdown
+
+	pages  map[string]*texttemplate.Template
+	layout *template.Template
+	mu     sync.RWMutex
+}
+
+// The data a layout is executed with
+//
+//   - Name: the name of the page
+//
+//   - Content: the HTML of the page
+//
+//   - Data: the data given to Context.HTML
+type MarkdownPage struct {
+	Name    string
+	Content template.HTML
+	Data    any
+}
+
+// Renders a Markdown page
+type markdownRender struct {
+	converter goldmark.Markdown
+	page      *texttemplate.Template
+	layout    *template.Template
+	name      string
+	data      any
+}
+
+// Parse the pages and the layout
+func (m *Markdown) Load(o TemplateOptions) error {
+	e := m.Extension
+	if e == "" {
+		e = ".md"
+	}
+	if m.Converter == nil {
+		m.Converter = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	}
+
+	var l *template.Template
+	if m.Layout != "" {
+		b, err := fs.ReadFile(m.FS, m.Layout)
+		if err != nil {
+			return err
+		}
+
+		l, err = template.New(m.Layout).Delims(o.Delims.Left, o.Delims.Right).Funcs(o.FuncMap).Parse(string(b))
+		if err != nil {
+			return err
+		}
+	}
+
+	ps := make(map[string]*texttemplate.Template)
+	err := fs.WalkDir(m.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != e {
+			return err
+		}
+
+		b, err := fs.ReadFile(m.FS, p)
+		if err != nil {
+			return err
+		}
+
+		t, err := texttemplate.New(p).Delims(o.Delims.Left, o.Delims.Right).Funcs(texttemplate.FuncMap(o.FuncMap)).Parse(string(b))
+		if err != nil {
+			return err
+		}
+
+		ps[p] = t
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.pages = ps
+	m.layout = l
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Create an instance of the HTMLRenderer, for the page
+func (m *Markdown) Instance(n string, d any) Renderer {
+	m.mu.RLock()
+	p, ok := m.pages[n]
+	l := m.layout
+	m.mu.RUnlock()
+	if !ok {
+		return htmlError{err: templateNotDefined(n)}
+	}
+
+	return markdownRender{
+		converter: m.Converter,
+		page:      p,
+		layout:    l,
+		name:      n,
+		data:      d,
+	}
+}
+
+// Render the page, nothing is written if it fails
+func (m markdownRender) Render(w http.ResponseWriter) error {
+	src := &bytes.Buffer{}
+	err := m.page.Execute(src, m.data)
+	if err != nil {
+		return err
+	}
+
+	b := &bytes.Buffer{}
+	err = m.converter.Convert(src.Bytes(), b)
+	if err != nil {
+		return err
+	}
+
+	if m.layout != nil {
+		h := b.String()
+		b.Reset()
+
+		err = m.layout.Execute(b, MarkdownPage{
+			Name:    m.name,
+			Content: template.HTML(h),
+			Data:    m.data,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeRendered(w, m, b.Bytes())
+}
+
+// Write the content type
+func (m markdownRender) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var markdownMockFS = fstest.MapFS{
+	"layout.html":   {Data: []byte(`<title>{{.Name}}</title><main>{{.Content}}</main><footer>{{upper .Data}}</footer>`)},
+	"docs/intro.md": {Data: []byte("# Hello {{.}}\n\n- one\n- two\n\n<script>alert(1)</script>\n")},
+}
+
+func TestMarkdown(t *testing.T) {
+	app := New()
+	app.funcMap["upper"] = strings.ToUpper
+	app.SetTemplateEngine(&Markdown{FS: markdownMockFS, Layout: "layout.html"})
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/pages/docs/intro.md", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "<title>docs/intro.md</title>")
+	assert.Contains(t, w.Body.String(), "<h1>Hello routey</h1>")
+	assert.Contains(t, w.Body.String(), "<li>one</li>")
+	assert.Contains(t, w.Body.String(), "<footer>ROUTEY</footer>")
+	assert.NotContains(t, w.Body.String(), "<script>")
+
+	app = New()
+	app.SetTemplateEngine(&Markdown{FS: markdownMockFS})
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+	w = serveRequest(app, http.MethodGet, "/pages/docs/intro.md", "", nil)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "<h1>Hello routey</h1>"))
+
+	w = serveRequest(app, http.MethodGet, "/pages/docs/missing.md", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestMarkdownLayoutError(t *testing.T) {
+	app := New()
+	app.SetTemplateEngine(&Markdown{FS: fstest.MapFS{
+		"layout.html": {Data: []byte(`{{.Missing.Field}}`)},
+		"index.md":    {Data: []byte(`# index`)},
+	}, Layout: "layout.html"})
+	app.Get("/pages", "/*page", func(c *Context) {
+		p, _ := c.Param("page")
+		c.HTML(http.StatusOK, p, "routey")
+	})
+
+	w := serveRequest(app, http.MethodGet, "/pages/index.md", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "index")
+}
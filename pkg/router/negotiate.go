@@ -0,0 +1,217 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// None of the offered formats are acceptable to the client
+var ErrNotAcceptable = errors.New("no acceptable format")
+
+// MIME types that can be offered in a Negotiation
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEYAML     = "application/x-yaml"
+	MIMETOML     = "application/toml"
+	MIMEMsgPack  = "application/msgpack"
+	MIMECBOR     = "application/cbor"
+	MIMEProtoBuf = "application/x-protobuf"
+	MIMEHTML     = "text/html"
+	MIMEPlain    = "text/plain"
+)
+
+// The formats offered when a Negotiation does not give any,
+// text/html is offered first when there is a HTMLName and protobuf last when the Data is a proto.Message
+var defaultOffered = []string{
+	MIMEJSON,
+	MIMEXML,
+	MIMEYAML,
+	MIMETOML,
+	MIMEMsgPack,
+	MIMECBOR,
+}
+
+// Aliases of the offered MIME types, clients commonly ask for these instead
+var mimeAliases = map[string][]string{
+	MIMEXML:      {"text/xml"},
+	MIMEYAML:     {"application/yaml", "text/yaml"},
+	MIMEMsgPack:  {"application/x-msgpack", "application/vnd.msgpack"},
+	MIMEProtoBuf: {"application/protobuf"},
+}
+
+// Negotiation struct
+//
+//   - Offered: the MIME types that can be rendered, in order of preference
+//
+//   - Data: the data to render
+//
+//   - HTMLName: the name of the HTML template, used when text/html is chosen
+type Negotiation struct {
+	Offered  []string
+	Data     any
+	HTMLName string
+}
+
+// A media range from an Accept header
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// Render the data of the Negotiation with the best format for the Accept header.
+// Responds with a 406 through the ErrorHandlerFunc if none of the offered formats are acceptable.
+func (c *Context) Negotiate(s int, n Negotiation) {
+	o := n.Offered
+	if len(o) == 0 {
+		o = defaultOffered
+		if n.HTMLName != "" {
+			o = append([]string{MIMEHTML}, o...)
+		}
+		if _, ok := n.Data.(proto.Message); ok {
+			o = append(o[:len(o):len(o)], MIMEProtoBuf)
+		}
+	}
+
+	addVary(c.writer.Header(), "Accept")
+
+	if !c.renderFormat(s, c.NegotiateFormat(o...), n) {
+		c.HandleError(http.StatusNotAcceptable, ErrNotAcceptable)
+	}
+}
+
+// Render the data of the Negotiation as the MIME type m, false when m can not be rendered
+func (c *Context) renderFormat(s int, m string, n Negotiation) bool {
+	switch m {
+	case MIMEJSON:
+		c.JSON(s, n.Data)
+	case MIMEXML:
+		c.XML(s, n.Data)
+	case MIMEYAML:
+		c.YAML(s, n.Data)
+	case MIMETOML:
+		c.TOML(s, n.Data)
+	case MIMEMsgPack:
+		c.MsgPack(s, n.Data)
+	case MIMECBOR:
+		c.CBOR(s, n.Data)
+	case MIMEProtoBuf:
+		c.ProtoBuf(s, n.Data)
+	case MIMEHTML:
+		c.HTML(s, n.HTMLName, n.Data)
+	case MIMEPlain:
+		c.String(s, "%v", n.Data)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Get the offered MIME type that best matches the Accept header of the request.
+// The first offered type is chosen when there is no Accept header,
+// an empty string is returned when none are acceptable.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+
+	h := c.GetHeader("Accept")
+	if h == "" {
+		return offered[0]
+	}
+
+	a := parseAccept(h)
+	best := ""
+	bestQ := 0.0
+
+	for _, o := range offered {
+		q := acceptQuality(a, o)
+		for _, alias := range mimeAliases[o] {
+			q = max(q, acceptQuality(a, alias))
+		}
+
+		if q > bestQ {
+			best = o
+			bestQ = q
+		}
+	}
+
+	return best
+}
+
+// Parse an Accept header into its media ranges
+func parseAccept(h string) []acceptRange {
+	r := make([]acceptRange, 0)
+
+	for _, p := range strings.Split(h, ",") {
+		m, ps, _ := strings.Cut(strings.TrimSpace(p), ";")
+		t, st, ok := strings.Cut(strings.ToLower(strings.TrimSpace(m)), "/")
+		if !ok || t == "" || st == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, e := range strings.Split(ps, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(e), "=")
+			if k != "q" {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(v, 64)
+			if err == nil && f >= 0 && f <= 1 {
+				q = f
+			}
+		}
+
+		r = append(r, acceptRange{typ: t, subtype: st, q: q})
+	}
+
+	return r
+}
+
+// Get the quality of a MIME type, using the most specific matching range
+func acceptQuality(a []acceptRange, m string) float64 {
+	t, st, _ := strings.Cut(m, "/")
+	q := 0.0
+	s := -1
+
+	for _, r := range a {
+		n := 0
+		switch {
+		case r.typ == t && r.subtype == st:
+			n = 2
+		case r.typ == t && r.subtype == "*":
+			n = 1
+		case r.typ == "*" && r.subtype == "*":
+			n = 0
+		default:
+			continue
+		}
+
+		if n > s {
+			s = n
+			q = r.q
+		}
+	}
+
+	return q
+}
+
+// Add a value to the Vary header, if it is not already there
+func addVary(h http.Header, v string) {
+	for _, e := range h.Values("Vary") {
+		for _, p := range strings.Split(e, ",") {
+			if strings.EqualFold(strings.TrimSpace(p), v) {
+				return
+			}
+		}
+	}
+
+	h.Add("Vary", v)
+}
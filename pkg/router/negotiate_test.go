@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func negotiateContext(accept string) (*Context, *httptest.ResponseRecorder) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+
+	return &Context{request: r, writer: w}, w
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	c, _ := negotiateContext("")
+	assert.Equal(t, MIMEJSON, c.NegotiateFormat(MIMEJSON, MIMEXML))
+
+	c, _ = negotiateContext("application/xml;q=0.9, application/json")
+	assert.Equal(t, MIMEJSON, c.NegotiateFormat(MIMEXML, MIMEJSON))
+
+	c, _ = negotiateContext("text/*;q=0.5, application/*;q=0.2")
+	assert.Equal(t, MIMEHTML, c.NegotiateFormat(MIMEJSON, MIMEHTML))
+
+	c, _ = negotiateContext("*/*;q=0.1, application/json;q=0")
+	assert.Equal(t, MIMEXML, c.NegotiateFormat(MIMEJSON, MIMEXML))
+
+	c, _ = negotiateContext("text/yaml")
+	assert.Equal(t, MIMEYAML, c.NegotiateFormat(MIMEJSON, MIMEYAML))
+
+	c, _ = negotiateContext("image/png")
+	assert.Equal(t, "", c.NegotiateFormat(MIMEJSON, MIMEXML))
+}
+
+type negotiateMock struct {
+	Name string
+}
+
+func TestNegotiate(t *testing.T) {
+	c, w := negotiateContext("application/xml")
+	c.Negotiate(http.StatusOK, Negotiation{
+		Offered: []string{MIMEJSON, MIMEXML},
+		Data:    negotiateMock{Name: "routey"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+	assert.Contains(t, w.Body.String(), "<Name>routey</Name>")
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	c, w := negotiateContext("image/png")
+	c.Negotiate(http.StatusOK, Negotiation{Data: M{"name": "routey"}})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.True(t, c.Aborted())
+
+	var err error
+	app := New()
+	app.SetErrorHandler(func(c *Context, s int, e error) {
+		err = e
+		c.String(s, "none")
+	})
+	c, w = negotiateContext("image/png")
+	c.app = app
+	c.Negotiate(http.StatusOK, Negotiation{Data: M{"name": "routey"}})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, "none", w.Body.String())
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+}
+
+func TestAddVary(t *testing.T) {
+	h := http.Header{}
+	addVary(h, "Accept")
+	addVary(h, "accept")
+	addVary(h, "Accept-Encoding")
+	assert.Equal(t, []string{"Accept", "Accept-Encoding"}, h.Values("Vary"))
+}
@@ -23,6 +23,9 @@ func parseParams(s string) (string, error) {
 			if strings.HasPrefix(segment, ":") {
 				p := segment[1:]
 				pattern += "(?P<" + p + ">\\w+)"
+			} else if strings.HasPrefix(segment, "*") {
+				p := segment[1:]
+				pattern += "(?P<" + p + ">.*)"
 			} else {
 				return "", errors.New("bad parameters found in the params")
 			}
@@ -45,6 +48,12 @@ func parsePathParams(s string) (string, error) {
 
 	for _, segment := range segments {
 		if segment != "" {
+			if strings.HasPrefix(segment, "*") {
+				p := segment[1:]
+				pattern += "(?:/(?P<" + p + ">.*))?"
+				break
+			}
+
 			pattern += "/"
 
 			if strings.HasPrefix(segment, ":") {
@@ -37,3 +37,13 @@ func TestParsePathParamsTwo(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, r, "/")
 }
+
+func TestParsePathParamsCatchAll(t *testing.T) {
+	r, err := parsePathParams("/static/*filepath")
+	assert.NoError(t, err)
+	assert.Equal(t, r, "/static(?:/(?P<filepath>.*))?")
+
+	r, err = parseParams("/:one/*rest")
+	assert.NoError(t, err)
+	assert.Equal(t, r, "/(?P<one>\\w+)/(?P<rest>.*)")
+}
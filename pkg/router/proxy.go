@@ -0,0 +1,104 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Trust the proxies in front of the App, given as addresses or CIDR ranges such as 10.0.0.0/8.
+// X-Forwarded-For is only used for the address of the client when the request comes from a trusted proxy,
+// as any client can send the header.
+func (a *App) SetTrustedProxies(p ...string) error {
+	t := make([]netip.Prefix, 0, len(p))
+	for _, s := range p {
+		if strings.Contains(s, "/") {
+			n, err := netip.ParsePrefix(s)
+			if err != nil {
+				return fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			t = append(t, n.Masked())
+			continue
+		}
+
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		ip = ip.Unmap()
+		t = append(t, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+
+	a.trustedProxies = t
+	return nil
+}
+
+// Is the address a trusted proxy
+func (a *App) trustedProxy(ip netip.Addr) bool {
+	for _, p := range a.trustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Did the request come from a trusted proxy
+func (c *Context) fromTrustedProxy() bool {
+	if c.app == nil || len(c.app.trustedProxies) == 0 {
+		return false
+	}
+
+	ip, ok := remoteIP(c.request.RemoteAddr)
+	return ok && c.app.trustedProxy(ip)
+}
+
+// Get the address of the client from X-Forwarded-For, the last address that is not a trusted proxy
+func (c *Context) forwardedFor() (netip.Addr, bool) {
+	var a []string
+	for _, v := range c.request.Header.Values("X-Forwarded-For") {
+		a = append(a, strings.Split(v, ",")...)
+	}
+
+	var ip netip.Addr
+	for i := len(a) - 1; i >= 0; i-- {
+		p, err := netip.ParseAddr(strings.TrimSpace(a[i]))
+		if err != nil {
+			break
+		}
+
+		ip = p.Unmap()
+		if !c.app.trustedProxy(ip) {
+			break
+		}
+	}
+
+	return ip, ip.IsValid()
+}
+
+// Get the first value of a header set by a trusted proxy, such as X-Forwarded-Proto, empty when the request did not come from one
+func (c *Context) forwardedHeader(n string) string {
+	if !c.fromTrustedProxy() {
+		return ""
+	}
+
+	v, _, _ := strings.Cut(c.request.Header.Get(n), ",")
+	return strings.TrimSpace(v)
+}
+
+// Get the IP of a RemoteAddr, with or without a port
+func remoteIP(r string) (netip.Addr, bool) {
+	h, _, err := net.SplitHostPort(r)
+	if err != nil {
+		h = r
+	}
+
+	ip, err := netip.ParseAddr(h)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return ip.Unmap(), true
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTrustedProxies(t *testing.T) {
+	app := New()
+	assert.Error(t, app.SetTrustedProxies("proxy"))
+	assert.Error(t, app.SetTrustedProxies("10.0.0.0/33"))
+	assert.NoError(t, app.SetTrustedProxies("10.0.0.0/8", "192.0.2.1", "2001:db8::/32"))
+
+	tests := []struct {
+		remote  string
+		forward []string
+		want    string
+	}{
+		{"192.0.2.1:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"192.0.2.1:1234", []string{"1.1.1.1, 203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"192.0.2.1:1234", []string{"1.1.1.1", "203.0.113.7"}, "203.0.113.7"},
+		{"192.0.2.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"192.0.2.1:1234", []string{"nonsense"}, "192.0.2.1"},
+		{"192.0.2.1:1234", nil, "192.0.2.1"},
+		{"[2001:db8::1]:1234", []string{"2001:db9::1"}, "2001:db9::1"},
+		{"[::ffff:192.0.2.1]:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"198.51.100.1:1234", []string{"203.0.113.7"}, "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		for _, v := range tt.forward {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+
+		c := Context{app: app, request: r}
+		a, err := c.RequestAddress()
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, a, tt.remote)
+	}
+}
@@ -0,0 +1,421 @@
+package router
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The client has made too many requests
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// How often expired keys are removed from a MemoryLimiterStore
+var LimiterSweepInterval = time.Minute
+
+// The state of a key, shared by every RateAlgorithm
+//
+//   - Count: requests made in the window, or the tokens left in a bucket
+//
+//   - Previous: requests made in the previous window
+//
+//   - Start: when the window started, or when the bucket was last refilled
+type RateState struct {
+	Count    float64
+	Previous float64
+	Start    time.Time
+}
+
+// The outcome of taking a request
+//
+//   - Allowed: can the request be served
+//
+//   - Limit: the requests allowed in a window
+//
+//   - Remaining: the requests left in the window
+//
+//   - Reset: how long until the limit is fully available again
+//
+//   - RetryAfter: how long until a denied request can be made
+type RateResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// A rate limiting algorithm
+//
+//   - Take: take a request from the state of a key
+//
+//   - Policy: the requests allowed in a window, keys are kept for twice the window
+type RateAlgorithm interface {
+	Take(s *RateState, now time.Time) RateResult
+	Policy() (limit int, window time.Duration)
+}
+
+// Keeps the RateState of each key. Update must change the state of a key
+// with f while no other update of the key runs, creating the state when there is none.
+// Keys not updated for ttl can be removed.
+type LimiterStore interface {
+	Update(key string, ttl time.Duration, f func(s *RateState))
+}
+
+// Get the key a request is limited by, an empty key is not limited
+type RateKeyFunc func(c *Context) string
+
+// Configure the RateLimit decorator
+//
+//   - Algorithm: the RateAlgorithm, defaults to a FixedWindow of 60 requests a minute
+//
+//   - Key: the key requests are limited by, defaults to KeyByIP
+//
+//   - Store: where the state of each key is kept, defaults to a MemoryLimiterStore
+//
+//   - Prefix: added to every key, so limiters can share a Store. Keys are hashed before they are stored or logged
+//
+//   - ErrorHandler: responds when a request is limited, defaults to aborting with 429 Too Many Requests
+type RateLimitConfig struct {
+	Algorithm    RateAlgorithm
+	Key          RateKeyFunc
+	Store        LimiterStore
+	Prefix       string
+	ErrorHandler ErrorHandlerFunc
+}
+
+// Token bucket, allowing bursts of requests while refilling at a steady rate
+type tokenBucket struct {
+	rate  float64
+	burst int
+}
+
+// Fixed window, counting requests in windows aligned to the clock
+type fixedWindow struct {
+	limit  int
+	window time.Duration
+}
+
+// Sliding window, weighting the count of the previous window by how much of it overlaps the sliding window
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+}
+
+// Limit how often clients can make requests, applied to the App, a group of routes or a single route.
+// Responses have RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers,
+// and limited requests have a Retry-After header.
+func RateLimit(cfg ...RateLimitConfig) DecoratorFunc {
+	o := RateLimitConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+	if o.Algorithm == nil {
+		o.Algorithm = FixedWindow(60, time.Minute)
+	}
+	if o.Key == nil {
+		o.Key = KeyByIP
+	}
+	if o.Store == nil {
+		o.Store = NewMemoryLimiterStore()
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = func(c *Context, s int, err error) {
+			c.HandleError(s, err)
+		}
+	}
+
+	l, w := o.Algorithm.Policy()
+	policy := fmt.Sprintf("%d;w=%d", l, int(math.Ceil(w.Seconds())))
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			k := o.Key(c)
+			if k == "" {
+				f(c)
+				return
+			}
+
+			k = rateKey(k)
+
+			var r RateResult
+			n := time.Now()
+			o.Store.Update(o.Prefix+k, 2*w, func(s *RateState) {
+				r = o.Algorithm.Take(s, n)
+			})
+
+			h := c.writer.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+			h.Set("RateLimit-Reset", rateSeconds(r.Reset))
+			h.Set("RateLimit-Policy", policy)
+
+			if !r.Allowed {
+				h.Set("Retry-After", rateSeconds(r.RetryAfter))
+				logWarn(c.logger(), ErrRateLimited.Error()+" for "+k, "RATE")
+				o.ErrorHandler(c, http.StatusTooManyRequests, ErrRateLimited)
+				return
+			}
+
+			f(c)
+		}
+	}
+}
+
+// Limit requests by the address of the client
+func KeyByIP(c *Context) string {
+	a, err := c.RequestAddress()
+	if err != nil {
+		return ""
+	}
+
+	return "ip:" + a
+}
+
+// Limit requests by the API key in the header
+func KeyByAPIKey(header string) RateKeyFunc {
+	return func(c *Context) string {
+		k := c.request.Header.Get(header)
+		if k == "" {
+			return ""
+		}
+
+		return "key:" + k
+	}
+}
+
+// Limit requests by the Subject of the Principal, falling back to the address of the client
+func KeyByUser(c *Context) string {
+	p := c.Principal()
+	if p == nil || p.Subject == "" {
+		return KeyByIP(c)
+	}
+
+	return "user:" + p.Subject
+}
+
+// Allow bursts of up to burst requests, refilled at rate requests a second.
+// Panics when rate or burst is not positive.
+func TokenBucket(rate float64, burst int) RateAlgorithm {
+	if !(rate > 0) || math.IsInf(rate, 1) || burst <= 0 {
+		panic(fmt.Sprintf("cannot create a token bucket with rate %v and burst %d", rate, burst))
+	}
+
+	return tokenBucket{rate: rate, burst: burst}
+}
+
+// Allow limit requests in each window, the windows are aligned to the clock.
+// Panics when limit or window is not positive.
+func FixedWindow(limit int, window time.Duration) RateAlgorithm {
+	rateWindow("fixed", limit, window)
+	return fixedWindow{limit: limit, window: window}
+}
+
+// Allow limit requests in any window, smoothing the bursts a FixedWindow allows at the edge of windows.
+// Panics when limit or window is not positive.
+func SlidingWindow(limit int, window time.Duration) RateAlgorithm {
+	rateWindow("sliding", limit, window)
+	return slidingWindow{limit: limit, window: window}
+}
+
+// Take a token, refilling the bucket for the time since it was last refilled
+func (t tokenBucket) Take(s *RateState, now time.Time) RateResult {
+	b := float64(t.burst)
+	if s.Start.IsZero() {
+		s.Count = b
+	} else {
+		s.Count = min(b, s.Count+now.Sub(s.Start).Seconds()*t.rate)
+	}
+	s.Start = now
+
+	r := RateResult{Limit: t.burst}
+	if s.Count >= 1 {
+		s.Count--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = rateDuration((1 - s.Count) / t.rate)
+	}
+
+	r.Remaining = int(s.Count)
+	r.Reset = rateDuration((b - s.Count) / t.rate)
+	return r
+}
+
+// A burst of tokens, in the time it takes to refill the bucket
+func (t tokenBucket) Policy() (int, time.Duration) {
+	return t.burst, rateDuration(float64(t.burst) / t.rate)
+}
+
+// Count the request in the current window
+func (f fixedWindow) Take(s *RateState, now time.Time) RateResult {
+	w := now.Truncate(f.window)
+	if !s.Start.Equal(w) {
+		s.Start = w
+		s.Count = 0
+	}
+
+	r := RateResult{
+		Limit: f.limit,
+		Reset: s.Start.Add(f.window).Sub(now),
+	}
+	if s.Count < float64(f.limit) {
+		s.Count++
+		r.Allowed = true
+	} else {
+		r.RetryAfter = r.Reset
+	}
+
+	r.Remaining = f.limit - int(s.Count)
+	return r
+}
+
+// The limit in each window
+func (f fixedWindow) Policy() (int, time.Duration) {
+	return f.limit, f.window
+}
+
+// Count the request, limited by the current window and the overlapping part of the previous window
+func (sw slidingWindow) Take(s *RateState, now time.Time) RateResult {
+	w := now.Truncate(sw.window)
+	if !s.Start.Equal(w) {
+		if w.Sub(s.Start) == sw.window {
+			s.Previous = s.Count
+		} else {
+			s.Previous = 0
+		}
+		s.Start = w
+		s.Count = 0
+	}
+
+	l := float64(sw.limit)
+	weight := 1 - float64(now.Sub(w))/float64(sw.window)
+	used := s.Previous*weight + s.Count
+
+	r := RateResult{Limit: sw.limit}
+	if used+1 <= l {
+		s.Count++
+		used++
+		r.Allowed = true
+	} else if s.Count+1 > l || s.Previous == 0 {
+		r.RetryAfter = w.Add(sw.window).Sub(now)
+	} else {
+		need := 1 - (l-1-s.Count)/s.Previous
+		r.RetryAfter = time.Duration(math.Ceil(need*float64(sw.window))) - now.Sub(w)
+	}
+
+	r.Remaining = max(0, int(l-math.Ceil(used)))
+	if s.Count > 0 {
+		r.Reset = w.Add(2 * sw.window).Sub(now)
+	} else if s.Previous > 0 {
+		r.Reset = w.Add(sw.window).Sub(now)
+	}
+	return r
+}
+
+// The limit in any window
+func (sw slidingWindow) Policy() (int, time.Duration) {
+	return sw.limit, sw.window
+}
+
+// Keeps the state of each key in memory, split into shards so keys in different shards do not wait on each other
+type MemoryLimiterStore struct {
+	shards []limiterShard
+}
+
+// A shard of a MemoryLimiterStore
+type limiterShard struct {
+	states map[string]*limiterEntry
+	swept  time.Time
+	mu     sync.Mutex
+}
+
+// The state of a key, and when it expires
+type limiterEntry struct {
+	state   RateState
+	expires time.Time
+}
+
+// Create a MemoryLimiterStore, with 32 shards unless a number is given
+func NewMemoryLimiterStore(shards ...int) *MemoryLimiterStore {
+	n := 32
+	if len(shards) > 0 && shards[0] > 0 {
+		n = shards[0]
+	}
+
+	m := &MemoryLimiterStore{shards: make([]limiterShard, n)}
+	for i := range m.shards {
+		m.shards[i].states = make(map[string]*limiterEntry)
+		m.shards[i].swept = time.Now()
+	}
+
+	return m
+}
+
+// Update the state of the key, under the lock of its shard
+func (m *MemoryLimiterStore) Update(key string, ttl time.Duration, f func(s *RateState)) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	s := &m.shards[h.Sum32()%uint32(len(m.shards))]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := time.Now()
+	if n.Sub(s.swept) >= LimiterSweepInterval {
+		s.swept = n
+		for k, e := range s.states {
+			if n.After(e.expires) {
+				delete(s.states, k)
+			}
+		}
+	}
+
+	e, ok := s.states[key]
+	if !ok || n.After(e.expires) {
+		e = &limiterEntry{}
+		s.states[key] = e
+	}
+
+	f(&e.state)
+	e.expires = n.Add(ttl)
+}
+
+// Get the number of keys kept, including expired keys that have not been removed
+func (m *MemoryLimiterStore) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		n += len(s.states)
+		s.mu.Unlock()
+	}
+
+	return n
+}
+
+// Hash a key, so keys such as API keys are not kept or logged
+func rateKey(k string) string {
+	return hex.EncodeToString(hashCredential(k)[:16])
+}
+
+// Panic when the limit or window of a window algorithm is not positive
+func rateWindow(n string, limit int, window time.Duration) {
+	if limit <= 0 || window <= 0 {
+		panic(fmt.Sprintf("cannot create a %s window with limit %d and window %s", n, limit, window))
+	}
+}
+
+// Convert seconds to a duration
+func rateDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Format a duration as whole seconds, rounded up
+func rateSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(max(d, 0).Seconds())))
+}
@@ -0,0 +1,262 @@
+package router
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var rateEpoch = time.Unix(1_700_000_040, 0)
+
+func TestTokenBucket(t *testing.T) {
+	a := TokenBucket(1, 3)
+	s := &RateState{}
+
+	for i := 2; i >= 0; i-- {
+		r := a.Take(s, rateEpoch)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, i, r.Remaining)
+		assert.Equal(t, 3, r.Limit)
+	}
+
+	r := a.Take(s, rateEpoch)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, time.Second, r.RetryAfter)
+	assert.Equal(t, 3*time.Second, r.Reset)
+
+	r = a.Take(s, rateEpoch.Add(1500*time.Millisecond))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 0, r.Remaining)
+
+	r = a.Take(s, rateEpoch.Add(time.Hour))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 2, r.Remaining)
+
+	l, w := a.Policy()
+	assert.Equal(t, 3, l)
+	assert.Equal(t, 3*time.Second, w)
+}
+
+func TestFixedWindow(t *testing.T) {
+	a := FixedWindow(2, time.Minute)
+	s := &RateState{}
+
+	assert.True(t, a.Take(s, rateEpoch).Allowed)
+	r := a.Take(s, rateEpoch.Add(10*time.Second))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 0, r.Remaining)
+	assert.Equal(t, 50*time.Second, r.Reset)
+
+	r = a.Take(s, rateEpoch.Add(20*time.Second))
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 40*time.Second, r.RetryAfter)
+
+	r = a.Take(s, rateEpoch.Add(time.Minute))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 1, r.Remaining)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	a := SlidingWindow(10, time.Minute)
+	s := &RateState{}
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, a.Take(s, rateEpoch.Add(50*time.Second)).Allowed)
+	}
+	r := a.Take(s, rateEpoch.Add(50*time.Second))
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 10*time.Second, r.RetryAfter)
+
+	// a fixed window would allow 10 more at the start of the next window
+	r = a.Take(s, rateEpoch.Add(61*time.Second))
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 5*time.Second, r.RetryAfter)
+
+	r = a.Take(s, rateEpoch.Add(66*time.Second))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 0, r.Remaining)
+
+	r = a.Take(s, rateEpoch.Add(90*time.Second))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 3, r.Remaining)
+	assert.Equal(t, 90*time.Second, r.Reset)
+
+	r = a.Take(s, rateEpoch.Add(5*time.Minute))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 9, r.Remaining)
+}
+
+func TestRateAlgorithmInvalid(t *testing.T) {
+	assert.Panics(t, func() { TokenBucket(0, 1) })
+	assert.Panics(t, func() { TokenBucket(-1, 1) })
+	assert.Panics(t, func() { TokenBucket(math.NaN(), 1) })
+	assert.Panics(t, func() { TokenBucket(1, 0) })
+	assert.Panics(t, func() { FixedWindow(0, time.Minute) })
+	assert.Panics(t, func() { FixedWindow(1, 0) })
+	assert.Panics(t, func() { SlidingWindow(-1, time.Minute) })
+	assert.Panics(t, func() { SlidingWindow(1, -time.Minute) })
+	assert.NotPanics(t, func() { TokenBucket(0.5, 1) })
+}
+
+func TestMemoryLimiterStore(t *testing.T) {
+	i := LimiterSweepInterval
+	LimiterSweepInterval = 0
+	defer func() {
+		LimiterSweepInterval = i
+	}()
+
+	m := NewMemoryLimiterStore(4)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Update("key", time.Hour, func(s *RateState) {
+				s.Count++
+			})
+		}()
+	}
+	wg.Wait()
+
+	var n float64
+	m.Update("key", time.Hour, func(s *RateState) {
+		n = s.Count
+	})
+	assert.Equal(t, float64(100), n)
+
+	m = NewMemoryLimiterStore(1)
+	m.Update("key", time.Hour, func(s *RateState) {})
+	m.Update("expired", -time.Second, func(s *RateState) {})
+	assert.Equal(t, 2, m.Len())
+	m.Update("other", time.Hour, func(s *RateState) {})
+	assert.Equal(t, 2, m.Len())
+}
+
+func TestRateLimit(t *testing.T) {
+	app := New()
+	app.Decorate(RateLimit(RateLimitConfig{Algorithm: FixedWindow(100, time.Hour)}))
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	app.Add(Get, "/limited", "", ok, RateLimit(RateLimitConfig{Algorithm: FixedWindow(2, time.Hour)}))
+	app.Add(Get, "/keys", "", ok, RateLimit(RateLimitConfig{Algorithm: TokenBucket(1, 1), Key: KeyByAPIKey("X-API-Key")}))
+	app.Add(Get, "/users", "", ok, Chain(func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.SetPrincipal(&Principal{Subject: c.GetHeader("X-User")})
+			f(c)
+		}
+	}, RateLimit(RateLimitConfig{Algorithm: SlidingWindow(1, time.Minute), Key: KeyByUser})))
+
+	w := serveRequest(app, http.MethodGet, "/limited", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=3600", w.Header().Get("RateLimit-Policy"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+
+	serveRequest(app, http.MethodGet, "/limited", "", nil)
+	w = serveRequest(app, http.MethodGet, "/limited", "", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = serveRequest(app, http.MethodGet, "/limited", "", map[string]string{"X-Forwarded-For": "10.0.0.1"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "a spoofed X-Forwarded-For is the same client")
+
+	for _, a := range []string{"[2001:db8::1]:1234", "[2001:db8::1]:5678", "[2001:db8::2]:1234"} {
+		r := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		r.RemoteAddr = a
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, a)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	r.RemoteAddr = "[2001:db8::1]:9999"
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "IPv6 clients are keyed by their whole address")
+
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/keys", "", nil).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/keys", "", nil).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/keys", "", map[string]string{"X-API-Key": "a"}).Code)
+	w = serveRequest(app, http.MethodGet, "/keys", "", map[string]string{"X-API-Key": "a"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/keys", "", map[string]string{"X-API-Key": "b"}).Code)
+
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/users", "", map[string]string{"X-User": "a"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(app, http.MethodGet, "/users", "", map[string]string{"X-User": "a"}).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/users", "", map[string]string{"X-User": "b"}).Code)
+
+	w = serveRequest(app, http.MethodGet, "/keys", "", nil)
+	assert.Equal(t, "100", w.Header().Get("RateLimit-Limit"), "the route overrides the headers of the App")
+	app.Get("/global", "", ok)
+	w = serveRequest(app, http.MethodGet, "/global", "", nil)
+	assert.Equal(t, "100", w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitTrustedProxy(t *testing.T) {
+	app := New()
+	assert.NoError(t, app.SetTrustedProxies("192.0.2.0/24"))
+	app.Add(Get, "/limited", "", func(c *Context) {}, RateLimit(RateLimitConfig{Algorithm: FixedWindow(1, time.Hour)}))
+
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/limited", "", map[string]string{"X-Forwarded-For": "203.0.113.1"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(app, http.MethodGet, "/limited", "", map[string]string{"X-Forwarded-For": "203.0.113.1"}).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(app, http.MethodGet, "/limited", "", map[string]string{"X-Forwarded-For": "203.0.113.2"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(app, http.MethodGet, "/limited", "", map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.2"}).Code,
+		"addresses added before the proxy can be spoofed")
+}
+
+func TestRateLimitErrorHandler(t *testing.T) {
+	app := New()
+	store := NewMemoryLimiterStore()
+	var err error
+	app.Add(Get, "/a", "", func(c *Context) {}, RateLimit(RateLimitConfig{
+		Algorithm: FixedWindow(1, time.Minute),
+		Store:     store,
+		Prefix:    "a:",
+		ErrorHandler: func(c *Context, s int, e error) {
+			err = e
+			c.String(s, "slow down")
+		},
+	}))
+	app.Add(Get, "/b", "", func(c *Context) {}, RateLimit(RateLimitConfig{Algorithm: FixedWindow(1, time.Minute), Store: store, Prefix: "b:"}))
+
+	serveRequest(app, http.MethodGet, "/a", "", nil)
+	w := serveRequest(app, http.MethodGet, "/a", "", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "slow down", w.Body.String())
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	assert.NotEqual(t, http.StatusTooManyRequests, serveRequest(app, http.MethodGet, "/b", "", nil).Code)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestRateLimitHashesKey(t *testing.T) {
+	app := New()
+	b := &bytes.Buffer{}
+	app.logger.SetOutput(b)
+
+	store := NewMemoryLimiterStore(1)
+	app.Add(Get, "/keyed", "", func(c *Context) {}, RateLimit(RateLimitConfig{
+		Algorithm: FixedWindow(1, time.Hour),
+		Key:       KeyByAPIKey("X-API-Key"),
+		Store:     store,
+	}))
+
+	h := map[string]string{"X-API-Key": "secret-key"}
+	serveRequest(app, http.MethodGet, "/keyed", "", h)
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(app, http.MethodGet, "/keyed", "", h).Code)
+	assert.NotContains(t, b.String(), "secret-key")
+	assert.Contains(t, b.String(), rateKey("key:secret-key"))
+
+	for k := range store.shards[0].states {
+		assert.NotContains(t, k, "secret-key")
+	}
+}
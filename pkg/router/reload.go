@@ -0,0 +1,352 @@
+package router
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Where templates are read from, so they can be watched for changes
+//
+//   - list: the names of the templates
+//
+//   - stat: get the size and modification time of a template
+//
+//   - read: read a template, to show the source of an error
+type templateSource struct {
+	list func() ([]string, error)
+	stat func(string) (fs.FileInfo, error)
+	read func(string) ([]byte, error)
+}
+
+// When a template last changed
+type templateStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// HTML Renderer used in debug mode, or with Config.Reload, polls the templates and parses them again only when they change.
+// The last templates that parsed are kept, and an error page is shown while the templates do not parse.
+//
+//   - load: parse the templates
+//
+//   - render: the last HTMLRenderer that was loaded
+//
+//   - failure: the error page of the last load, nil if it succeeded
+type htmlReload struct {
+	load   func() (HTMLRenderer, error)
+	source templateSource
+	logger *logrus.Logger
+
+	render  HTMLRenderer
+	failure *templateFailure
+	stamps  map[string]templateStamp
+	mu      sync.RWMutex
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// A template that failed to parse, shown in the browser
+type templateFailure struct {
+	Message string
+	File    string
+	Line    int
+	Source  []templateLine
+	Overlay bool
+}
+
+// A line of a template that failed to parse
+type templateLine struct {
+	Number int
+	Text   string
+	Error  bool
+}
+
+// Renders the error page, or the page with the error shown over it
+type templateError struct {
+	failure templateFailure
+	page    Renderer
+}
+
+// Matches the file and line of a template error, such as template: index.html:3: unexpected EOF
+var templateErrorRegexp = regexp.MustCompile(`template:\s?([^:\s]+):(\d+):`)
+
+var templateErrorPage = template.Must(template.New("error").Parse(`{{if not .Overlay}}<!DOCTYPE html>
+<html>
+<head><title>Template error</title></head>
+<body style="margin:0">
+{{end}}<div style="position:fixed;inset:0;z-index:2147483647;overflow:auto;background:#1e1e1e;color:#eee;font:14px/1.5 monospace;padding:2em">
+<h1 style="color:#ff6b6b;font-size:1.4em">Template error</h1>
+<p>{{.Message}}</p>
+{{if .File}}<p>{{.File}}{{if .Line}}:{{.Line}}{{end}}</p>{{end}}
+{{if .Source}}<pre style="background:#111;padding:1em">{{range .Source}}<div{{if .Error}} style="background:#5c1f1f"{{end}}>{{printf "%4d" .Number}}  {{.Text}}</div>{{end}}</pre>{{end}}
+{{if .Overlay}}<p style="color:#aaa">The last templates that parsed are still being served.</p>{{end}}
+</div>
+{{if not .Overlay}}</body>
+</html>
+{{end}}`))
+
+// Watch templates in debug mode, replacing the current HTMLRenderer
+func (a *App) reloadHTML(load func() (HTMLRenderer, error), source templateSource) {
+	h := &htmlReload{
+		load:   load,
+		source: source,
+		logger: a.logger,
+		stop:   make(chan struct{}),
+	}
+	h.stamps, _ = h.stamp()
+	h.reload(nil)
+
+	go h.watch(a.reloadInterval)
+
+	a.setHTMLRender(h)
+}
+
+// Set the HTMLRenderer, stopping the previous one from watching templates
+func (a *App) setHTMLRender(r HTMLRenderer) {
+	o, ok := a.htmlRender.(*htmlReload)
+	if ok && o != r {
+		o.Close()
+	}
+
+	a.htmlRender = r
+}
+
+// Create an instance of the HTMLRenderer, showing the error while the templates do not parse
+func (h *htmlReload) Instance(n string, d any) Renderer {
+	h.mu.RLock()
+	r, f := h.render, h.failure
+	h.mu.RUnlock()
+
+	if f == nil {
+		return r.Instance(n, d)
+	}
+
+	e := templateError{failure: *f}
+	if r != nil {
+		e.page = r.Instance(n, d)
+		e.failure.Overlay = true
+	}
+	return e
+}
+
+// Stop watching the templates
+func (h *htmlReload) Close() {
+	h.once.Do(func() {
+		close(h.stop)
+	})
+}
+
+// Poll the templates until closed
+func (h *htmlReload) watch(i time.Duration) {
+	if i <= 0 {
+		return
+	}
+
+	t := time.NewTicker(i)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-t.C:
+			h.check()
+		}
+	}
+}
+
+// Parse the templates again if any of them changed
+func (h *htmlReload) check() {
+	s, err := h.stamp()
+	if err != nil {
+		logError(h.logger, err.Error(), "TEMPLATE")
+		return
+	}
+
+	c := changedTemplates(h.stamps, s)
+	if len(c) == 0 {
+		return
+	}
+
+	h.stamps = s
+	h.reload(c)
+}
+
+// Parse the templates, keeping the last HTMLRenderer if they do not parse
+func (h *htmlReload) reload(changed []string) {
+	r, err := h.load()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err != nil {
+		f := h.fail(err)
+		h.failure = &f
+		logError(h.logger, err.Error(), "TEMPLATE")
+		return
+	}
+
+	h.render = r
+	h.failure = nil
+
+	if changed != nil {
+		h.logger.WithFields(logrus.Fields{
+			"TEMPLATE": "Reload",
+		}).Info("reloaded templates, changed " + strings.Join(changed, ", "))
+	}
+}
+
+// Describe the error, with the source around the line that failed
+func (h *htmlReload) fail(err error) templateFailure {
+	f := templateFailure{Message: err.Error()}
+
+	m := templateErrorRegexp.FindStringSubmatch(f.Message)
+	if m == nil {
+		return f
+	}
+	f.File = m[1]
+	f.Line, _ = strconv.Atoi(m[2])
+
+	n := ""
+	for k := range h.stamps {
+		if k == f.File || path.Base(filepath.ToSlash(k)) == f.File {
+			n = k
+			break
+		}
+	}
+	if n == "" {
+		return f
+	}
+
+	b, err := h.source.read(n)
+	if err != nil {
+		return f
+	}
+	f.File = n
+
+	ls := strings.Split(string(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))), "\n")
+	for i := max(f.Line-4, 0); i < min(f.Line+3, len(ls)); i++ {
+		f.Source = append(f.Source, templateLine{Number: i + 1, Text: ls[i], Error: i+1 == f.Line})
+	}
+
+	return f
+}
+
+// Get when each template last changed
+func (h *htmlReload) stamp() (map[string]templateStamp, error) {
+	ns, err := h.source.list()
+	if err != nil {
+		return nil, err
+	}
+
+	s := make(map[string]templateStamp, len(ns))
+	for _, n := range ns {
+		i, err := h.source.stat(n)
+		if err != nil {
+			continue
+		}
+
+		s[n] = templateStamp{size: i.Size(), modTime: i.ModTime()}
+	}
+
+	return s, nil
+}
+
+// Get the templates that were added, removed or changed
+func changedTemplates(o map[string]templateStamp, n map[string]templateStamp) []string {
+	c := make([]string, 0)
+
+	for k, v := range n {
+		e, ok := o[k]
+		if !ok || e.size != v.size || !e.modTime.Equal(v.modTime) {
+			c = append(c, k)
+		}
+	}
+	for k := range o {
+		_, ok := n[k]
+		if !ok {
+			c = append(c, k)
+		}
+	}
+
+	sort.Strings(c)
+	return c
+}
+
+// Templates read from the OS filesystem
+func osTemplates(list func() ([]string, error)) templateSource {
+	return templateSource{
+		list: list,
+		stat: os.Stat,
+		read: os.ReadFile,
+	}
+}
+
+// Templates read from a fs.FS, listed by the patterns or every file when there are none
+func fsTemplates(fsys fs.FS, patterns ...string) templateSource {
+	return templateSource{
+		list: func() ([]string, error) {
+			ns := make([]string, 0)
+
+			if len(patterns) == 0 {
+				err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+					if err == nil && !d.IsDir() {
+						ns = append(ns, p)
+					}
+					return err
+				})
+				return ns, err
+			}
+
+			for _, p := range patterns {
+				m, err := fs.Glob(fsys, p)
+				if err != nil {
+					return nil, err
+				}
+				ns = append(ns, m...)
+			}
+			return ns, nil
+		},
+		stat: func(n string) (fs.FileInfo, error) {
+			return fs.Stat(fsys, n)
+		},
+		read: func(n string) ([]byte, error) {
+			return fs.ReadFile(fsys, n)
+		},
+	}
+}
+
+// Render the page with the error shown over it, or the error page when there is no page
+func (t templateError) Render(w http.ResponseWriter) error {
+	if t.page == nil {
+		t.WriteContentType(w)
+		w.WriteHeader(http.StatusInternalServerError)
+		return templateErrorPage.Execute(w, t.failure)
+	}
+
+	err := t.page.Render(w)
+	if err != nil {
+		return err
+	}
+
+	return templateErrorPage.Execute(w, t.failure)
+}
+
+// Write the content type
+func (t templateError) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLReload(t *testing.T) {
+	d := t.TempDir()
+	p := filepath.Join(d, "index.html")
+	assert.NoError(t, os.WriteFile(p, []byte(`hello {{.}}`), 0o644))
+
+	app := New(Config{Reload: true, ReloadInterval: 5 * time.Millisecond})
+	app.LoadHTMLGlob(filepath.Join(d, "*.html"))
+	defer app.setHTMLRender(nil)
+	app.Get("/page", "", func(c *Context) {
+		c.HTML(http.StatusOK, "index.html", "routey")
+	})
+
+	assert.Equal(t, "hello routey", serveRequest(app, http.MethodGet, "/page", "", nil).Body.String())
+
+	assert.NoError(t, os.WriteFile(p, []byte(`goodbye {{.}}`), 0o644))
+	assert.Eventually(t, func() bool {
+		return serveRequest(app, http.MethodGet, "/page", "", nil).Body.String() == "goodbye routey"
+	}, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, os.WriteFile(p, []byte("line one\ngoodbye {{if}}\n"), 0o644))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(serveRequest(app, http.MethodGet, "/page", "", nil).Body.String(), "Template error")
+	}, time.Second, 5*time.Millisecond)
+
+	w := serveRequest(app, http.MethodGet, "/page", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "goodbye routey"))
+	assert.Contains(t, w.Body.String(), p+":2")
+	assert.Contains(t, w.Body.String(), "goodbye {{if}}")
+
+	assert.NoError(t, os.WriteFile(p, []byte(`fixed {{.}}`), 0o644))
+	assert.Eventually(t, func() bool {
+		return serveRequest(app, http.MethodGet, "/page", "", nil).Body.String() == "fixed routey"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHTMLReloadInvalid(t *testing.T) {
+	d := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(d, "index.html"), []byte(`{{if}}`), 0o644))
+
+	app := New(Config{Reload: true})
+	app.LoadHTMLGlob(filepath.Join(d, "*.html"))
+	defer app.setHTMLRender(nil)
+	app.Get("/page", "", func(c *Context) {
+		c.HTML(http.StatusOK, "index.html", nil)
+	})
+
+	w := serveRequest(app, http.MethodGet, "/page", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "Template error")
+	assert.Contains(t, w.Body.String(), "index.html:1")
+
+	assert.Panics(t, func() {
+		New(Config{Debug: false}).LoadHTMLGlob(filepath.Join(d, "*.html"))
+	})
+}
+
+func TestHTMLReloadDebug(t *testing.T) {
+	d := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(d, "index.html"), []byte(`hello`), 0o644))
+
+	cases := []struct {
+		app    *App
+		reload bool
+	}{
+		{New(), true},
+		{New(Config{Debug: true}), true},
+		{New(Config{Debug: false}), false},
+		{New(Config{Debug: false, Reload: true}), true},
+	}
+
+	for i, tc := range cases {
+		tc.app.LoadHTMLGlob(filepath.Join(d, "*.html"))
+		defer tc.app.setHTMLRender(nil)
+		_, ok := tc.app.htmlRender.(*htmlReload)
+		assert.Equal(t, tc.reload, ok, i)
+		assert.Equal(t, 500*time.Millisecond, tc.app.reloadInterval)
+	}
+}
+
+func TestChangedTemplates(t *testing.T) {
+	n := time.Now()
+	o := map[string]templateStamp{
+		"a.html": {size: 1, modTime: n},
+		"b.html": {size: 1, modTime: n},
+		"c.html": {size: 1, modTime: n},
+	}
+
+	assert.Empty(t, changedTemplates(o, o))
+	assert.Equal(t, []string{"a.html", "c.html", "d.html"}, changedTemplates(o, map[string]templateStamp{
+		"a.html": {size: 2, modTime: n},
+		"b.html": {size: 1, modTime: n},
+		"d.html": {size: 1, modTime: n},
+	}))
+}
@@ -1,16 +1,29 @@
 package router
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
 )
 
 var (
-	jsonContentType  = []string{"application/json; charset=utf-8"}
-	tomlContentType  = []string{"application/toml; charset=utf-8"}
-	yamlContentType  = []string{"application/x-yaml; charset=utf-8"}
-	xmlContentType   = []string{"application/xml; charset=utf-8"}
-	htmlContentType  = []string{"text/html; charset=utf-8"}
-	plainContentType = []string{"text/plain; charset=utf-8"}
+	jsonContentType     = []string{"application/json; charset=utf-8"}
+	tomlContentType     = []string{"application/toml; charset=utf-8"}
+	yamlContentType     = []string{"application/x-yaml; charset=utf-8"}
+	xmlContentType      = []string{"application/xml; charset=utf-8"}
+	msgpackContentType  = []string{"application/msgpack"}
+	cborContentType     = []string{"application/cbor"}
+	protobufContentType = []string{"application/x-protobuf"}
+	ndjsonContentType   = []string{"application/x-ndjson"}
+	htmlContentType     = []string{"text/html; charset=utf-8"}
+	plainContentType    = []string{"text/plain; charset=utf-8"}
 )
 
 // Writes the content type to the response header
@@ -21,8 +34,203 @@ func writeContentType(w http.ResponseWriter, v []string) {
 	}
 }
 
-// An interface for Rendering in a response
+// An interface for Rendering in a response.
+//
+// Render should not write to the http.ResponseWriter until it knows it can succeed,
+// so that an error can still be responded with.
 type Renderer interface {
 	Render(http.ResponseWriter) error
 	WriteContentType(http.ResponseWriter)
 }
+
+// JSON renderer
+type JSON struct {
+	Data any
+}
+
+// XML renderer
+type XML struct {
+	Data any
+}
+
+// YAML renderer
+type YAML struct {
+	Data any
+}
+
+// TOML renderer
+type TOML struct {
+	Data any
+}
+
+// MessagePack renderer
+type MsgPack struct {
+	Data any
+}
+
+// CBOR renderer
+type CBOR struct {
+	Data any
+}
+
+// Protocol Buffers renderer, Data must be a proto.Message
+type ProtoBuf struct {
+	Data any
+}
+
+// Render the JSON
+func (j JSON) Render(w http.ResponseWriter) error {
+	b, err := json.Marshal(j.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to json: %w", j.Data, err)
+	}
+
+	return writeRendered(w, j, b)
+}
+
+// Write the content type
+func (j JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render the XML
+func (x XML) Render(w http.ResponseWriter) error {
+	b, err := xml.Marshal(x.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to xml: %w", x.Data, err)
+	}
+
+	return writeRendered(w, x, b)
+}
+
+// Write the content type
+func (x XML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, xmlContentType)
+}
+
+// Render the YAML
+func (y YAML) Render(w http.ResponseWriter) error {
+	b, err := yaml.Marshal(y.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to yaml: %w", y.Data, err)
+	}
+
+	return writeRendered(w, y, b)
+}
+
+// Write the content type
+func (y YAML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, yamlContentType)
+}
+
+// Render the TOML
+func (t TOML) Render(w http.ResponseWriter) error {
+	b, err := toml.Marshal(t.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to toml: %w", t.Data, err)
+	}
+
+	return writeRendered(w, t, b)
+}
+
+// Write the content type
+func (t TOML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, tomlContentType)
+}
+
+// Render the MessagePack
+func (m MsgPack) Render(w http.ResponseWriter) error {
+	b, err := msgpack.Marshal(m.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to msgpack: %w", m.Data, err)
+	}
+
+	return writeRendered(w, m, b)
+}
+
+// Write the content type
+func (m MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
+
+// Render the CBOR
+func (c CBOR) Render(w http.ResponseWriter) error {
+	b, err := cbor.Marshal(c.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to cbor: %w", c.Data, err)
+	}
+
+	return writeRendered(w, c, b)
+}
+
+// Write the content type
+func (c CBOR) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, cborContentType)
+}
+
+// Render the Protocol Buffers
+func (p ProtoBuf) Render(w http.ResponseWriter) error {
+	m, ok := p.Data.(proto.Message)
+	if !ok {
+		return fmt.Errorf("unable to marshal %T to protobuf: not a proto.Message", p.Data)
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %T to protobuf: %w", p.Data, err)
+	}
+
+	return writeRendered(w, p, b)
+}
+
+// Write the content type
+func (p ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, protobufContentType)
+}
+
+// Write the content type and then the marshalled body
+func writeRendered(w http.ResponseWriter, r Renderer, b []byte) error {
+	r.WriteContentType(w)
+
+	_, err := w.Write(b)
+	return err
+}
+
+// Defers writing the status until the body is first written,
+// so that nothing is committed if a Renderer fails before writing.
+type renderWriter struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+// Write the status, if it has not been written
+func (r *renderWriter) WriteHeader(s int) {
+	if r.written {
+		return
+	}
+
+	r.written = true
+	r.ResponseWriter.WriteHeader(s)
+}
+
+// Write the body, writing the status first
+func (r *renderWriter) Write(b []byte) (int, error) {
+	r.WriteHeader(r.status)
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush the underlying writer, if it can be flushed
+func (r *renderWriter) Flush() {
+	r.WriteHeader(r.status)
+
+	f, ok := r.ResponseWriter.(http.Flusher)
+	if ok {
+		f.Flush()
+	}
+}
+
+// Get the underlying writer, used by http.ResponseController
+func (r *renderWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -1 +1,74 @@
 package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingRenderer struct {
+	write bool
+}
+
+func (f failingRenderer) Render(w http.ResponseWriter) error {
+	if f.write {
+		w.Write([]byte("partial"))
+	}
+
+	return errors.New("failed")
+}
+
+func (f failingRenderer) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, plainContentType)
+}
+
+func TestRenderers(t *testing.T) {
+	d := negotiateMock{Name: "routey"}
+	cases := map[string]Renderer{
+		"application/json; charset=utf-8":   JSON{Data: d},
+		"application/x-yaml; charset=utf-8": YAML{Data: d},
+		"application/toml; charset=utf-8":   TOML{Data: d},
+		"application/xml; charset=utf-8":    XML{Data: d},
+		"application/msgpack":               MsgPack{Data: d},
+		"application/cbor":                  CBOR{Data: d},
+	}
+
+	for ct, r := range cases {
+		w := httptest.NewRecorder()
+		err := r.Render(w)
+		assert.NoError(t, err)
+		assert.Equal(t, ct, w.Header().Get("Content-Type"))
+		assert.NotEmpty(t, w.Body.Bytes())
+	}
+}
+
+func TestRenderersMarshalError(t *testing.T) {
+	cases := []Renderer{
+		JSON{Data: make(chan int)},
+		XML{Data: make(chan int)},
+		ProtoBuf{Data: negotiateMock{}},
+	}
+
+	for _, r := range cases {
+		w := httptest.NewRecorder()
+		err := r.Render(w)
+		assert.Error(t, err)
+		assert.Empty(t, w.Header().Get("Content-Type"))
+		assert.False(t, w.Flushed)
+		assert.Empty(t, w.Body.Bytes())
+	}
+}
+
+func TestRenderWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := &renderWriter{ResponseWriter: w, status: http.StatusCreated}
+	assert.False(t, r.written)
+
+	r.Write([]byte("routey"))
+	assert.True(t, r.written)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, w, r.Unwrap())
+}
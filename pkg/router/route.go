@@ -16,6 +16,14 @@ import (
 //
 //   - DecoratorFunc: decorator function of the route
 //
+//   - MaxBodySize: the largest request body in bytes, overrides the App when above 0
+//
+//   - CSRFExempt: the route is not checked by the CSRF decorator
+//
+//   - Roles: the roles allowed to use the route, checked by the Authorizer of the App
+//
+//   - Policies: attribute based policies that must all allow a request to use the route
+//
 //   - regexp: regexp used for params
 type Route struct {
 	Path          string
@@ -23,6 +31,10 @@ type Route struct {
 	Method        Method
 	HandlerFunc   HandlerFunc
 	DecoratorFunc DecoratorFunc
+	MaxBodySize   int64
+	CSRFExempt    bool
+	Roles         []string
+	Policies      []PolicyFunc
 
 	regexp    *regexp.Regexp
 	rawPath   string
@@ -75,6 +87,10 @@ func (r *Route) Copy() *Route {
 		Method:        r.Method,
 		HandlerFunc:   r.HandlerFunc,
 		DecoratorFunc: r.DecoratorFunc,
+		MaxBodySize:   r.MaxBodySize,
+		CSRFExempt:    r.CSRFExempt,
+		Roles:         r.Roles,
+		Policies:      r.Policies,
 
 		regexp:    r.regexp,
 		formatted: r.formatted,
@@ -0,0 +1,678 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// The session does not exist in the store, or has expired
+	ErrSessionNotFound = errors.New("session not found")
+	// The session is too large to be stored in a cookie
+	ErrSessionTooLarge = errors.New("session is too large for a cookie")
+)
+
+// How often expired sessions are removed from a MemorySessionStore or FileSessionStore
+var SessionSweepInterval = time.Minute
+
+// Cookies larger than this are not kept by browsers
+const maxCookieSize = 4096
+
+// Length of a session ID, 32 random bytes encoded as base64url
+const sessionIDLength = 43
+
+// A session as it is kept by a SessionStore
+//
+//   - ID: the ID of the session
+//
+//   - Values: the values of the session
+//
+//   - Flashes: values kept until they are next read
+//
+//   - Expires: when the session expires, moved forward on every request
+type SessionData struct {
+	ID      string
+	Values  map[string]any
+	Flashes map[string][]any
+	Expires time.Time
+}
+
+// Keeps sessions between requests. The token is the value of the session cookie,
+// the ID for stores that keep sessions on the server or the session itself for a CookieSessionStore.
+// Values are encoded with encoding/gob by stores that encode them, so custom types must be registered with gob.Register.
+//
+//   - Load: get the session of the token, ErrSessionNotFound if it does not exist or has expired
+//
+//   - Save: keep the session until it expires, returning the token of the session
+//
+//   - Delete: remove the session of the token
+type SessionStore interface {
+	Load(token string) (SessionData, error)
+	Save(d SessionData) (string, error)
+	Delete(token string) error
+}
+
+// Configure the Sessions decorator
+//
+//   - Store: where sessions are kept, defaults to a MemorySessionStore
+//
+//   - Name: the name of the session cookie, defaults to session
+//
+//   - MaxAge: how long a session lasts without a request, defaults to 24 hours
+//
+//   - Path: the path of the cookie, defaults to /
+//
+//   - Domain: the domain of the cookie
+//
+//   - SameSite: the SameSite of the cookie, defaults to Lax
+type SessionConfig struct {
+	Store    SessionStore
+	Name     string
+	MaxAge   time.Duration
+	Path     string
+	Domain   string
+	SameSite http.SameSite
+}
+
+// The session of a request, loaded from the store the first time it is used.
+// It is saved before the response is written, moving its expiry forward,
+// and an empty session is removed along with its cookie.
+type Session struct {
+	cfg    *SessionConfig
+	cookie string
+
+	id      string
+	token   string
+	values  map[string]any
+	flashes map[string][]any
+	stale   []string
+	loaded  bool
+	saved   bool
+	mu      sync.Mutex
+}
+
+// Keep a session for each client, available through Context.Session
+func Sessions(cfg ...SessionConfig) DecoratorFunc {
+	o := SessionConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+	if o.Store == nil {
+		o.Store = NewMemorySessionStore()
+	}
+	if o.Name == "" {
+		o.Name = "session"
+	}
+	if o.MaxAge <= 0 {
+		o.MaxAge = 24 * time.Hour
+	}
+	if o.Path == "" {
+		o.Path = "/"
+	}
+
+	return func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			s := &Session{cfg: &o}
+			ck, err := c.request.Cookie(o.Name)
+			if err == nil {
+				s.cookie = ck.Value
+			}
+
+			p := c.session
+			c.session = s
+
+			w := &sessionWriter{ResponseWriter: c.writer}
+			w.commit = func() {
+				s.commit(c)
+			}
+			pw := c.writer
+			c.writer = w
+			defer func() {
+				c.writer = pw
+				c.session = p
+				w.save()
+			}()
+
+			f(c)
+		}
+	}
+}
+
+// Get the Session of the request, nil when the Sessions decorator is not used
+func (c *Context) Session() *Session {
+	return c.session
+}
+
+// Get the ID of the session
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	return s.id
+}
+
+// Get a value of the session
+func (s *Session) Get(k string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	v, ok := s.values[k]
+	return v, ok
+}
+
+// Set a value of the session
+func (s *Session) Set(k string, v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	s.values[k] = v
+}
+
+// Delete a value of the session
+func (s *Session) Delete(k string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	delete(s.values, k)
+}
+
+// Remove every value and flash, the session and its cookie are removed once the response is written
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	s.values = make(map[string]any)
+	s.flashes = make(map[string][]any)
+}
+
+// Add a flash, a value kept until it is read with Flashes, such as a message shown after a redirect
+func (s *Session) Flash(k string, v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	s.flashes[k] = append(s.flashes[k], v)
+}
+
+// Get and remove the flashes of the key
+func (s *Session) Flashes(k string) []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	f := s.flashes[k]
+	delete(s.flashes, k)
+	return f
+}
+
+// Give the session a new ID, keeping its values, and remove the old one from the store.
+// Call it whenever the privileges of a session change, such as logging in, to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	if s.token != "" {
+		s.stale = append(s.stale, s.token)
+	}
+	s.id = sessionID()
+	s.token = ""
+}
+
+// Load the session from the store, starting a new one when there is none
+func (s *Session) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+
+	if s.cookie != "" {
+		d, err := s.cfg.Store.Load(s.cookie)
+		if err == nil {
+			s.id = d.ID
+			s.token = s.cookie
+			s.values = d.Values
+			s.flashes = d.Flashes
+		}
+	}
+
+	if s.id == "" {
+		s.id = sessionID()
+	}
+	if s.values == nil {
+		s.values = make(map[string]any)
+	}
+	if s.flashes == nil {
+		s.flashes = make(map[string][]any)
+	}
+}
+
+// Save the session and write its cookie, a session without a cookie that was not used is left alone
+func (s *Session) commit(c *Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saved || (!s.loaded && s.cookie == "") {
+		return
+	}
+	s.saved = true
+	s.load()
+
+	for _, t := range s.stale {
+		err := s.cfg.Store.Delete(t)
+		if err != nil {
+			logError(c.logger(), err.Error(), "SESSION")
+		}
+	}
+
+	if len(s.values) == 0 && len(s.flashes) == 0 {
+		if s.token != "" {
+			err := s.cfg.Store.Delete(s.token)
+			if err != nil {
+				logError(c.logger(), err.Error(), "SESSION")
+			}
+		}
+		if s.cookie != "" {
+			s.writeCookie(c, "", -1)
+		}
+		return
+	}
+
+	t, err := s.cfg.Store.Save(SessionData{
+		ID:      s.id,
+		Values:  s.values,
+		Flashes: s.flashes,
+		Expires: time.Now().Add(s.cfg.MaxAge),
+	})
+	if err != nil {
+		logError(c.logger(), err.Error(), "SESSION")
+		return
+	}
+
+	s.token = t
+	s.writeCookie(c, t, int(s.cfg.MaxAge/time.Second))
+}
+
+// Write the session cookie
+func (s *Session) writeCookie(c *Context, v string, age int) {
+	ck := &http.Cookie{
+		Name:     s.cfg.Name,
+		Value:    v,
+		Path:     s.cfg.Path,
+		Domain:   s.cfg.Domain,
+		MaxAge:   age,
+		HttpOnly: true,
+		SameSite: s.cfg.SameSite,
+	}
+	if age > 0 {
+		ck.Expires = time.Now().Add(time.Duration(age) * time.Second)
+	} else {
+		ck.Expires = time.Unix(0, 0)
+	}
+
+	c.SetCookie(ck)
+}
+
+// Saves the session before the response is written, so its cookie can be set
+type sessionWriter struct {
+	http.ResponseWriter
+	commit func()
+	once   sync.Once
+}
+
+// Save the session, once
+func (w *sessionWriter) save() {
+	w.once.Do(w.commit)
+}
+
+// Save the session, then write the status
+func (w *sessionWriter) WriteHeader(s int) {
+	if s >= 200 {
+		w.save()
+	}
+	w.ResponseWriter.WriteHeader(s)
+}
+
+// Save the session, then write the body
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.save()
+	return w.ResponseWriter.Write(b)
+}
+
+// Save the session, then flush the underlying writer
+func (w *sessionWriter) Flush() {
+	w.save()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack the connection, saving the session first
+func (w *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.save()
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Get the underlying writer, used by http.ResponseController
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Keeps sessions in memory, expired sessions are removed as new ones are saved
+type MemorySessionStore struct {
+	sessions map[string]SessionData
+	swept    time.Time
+	mu       sync.Mutex
+}
+
+// Create a MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]SessionData),
+		swept:    time.Now(),
+	}
+}
+
+// Get the session of the ID
+func (m *MemorySessionStore) Load(token string) (SessionData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.sessions[token]
+	if !ok {
+		return SessionData{}, ErrSessionNotFound
+	}
+	if time.Now().After(d.Expires) {
+		delete(m.sessions, token)
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	return copySessionData(d), nil
+}
+
+// Keep the session, returning its ID
+func (m *MemorySessionStore) Save(d SessionData) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := time.Now()
+	if n.Sub(m.swept) >= SessionSweepInterval {
+		m.swept = n
+		for k, v := range m.sessions {
+			if n.After(v.Expires) {
+				delete(m.sessions, k)
+			}
+		}
+	}
+
+	m.sessions[d.ID] = copySessionData(d)
+	return d.ID, nil
+}
+
+// Remove the session of the ID
+func (m *MemorySessionStore) Delete(token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, token)
+	return nil
+}
+
+// Get the number of sessions kept, including expired sessions that have not been removed
+func (m *MemorySessionStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.sessions)
+}
+
+// Keeps sessions as files in a directory, named by their ID.
+// The modification time of a file is when it expires, expired files are removed as new sessions are saved.
+type FileSessionStore struct {
+	dir   string
+	swept time.Time
+	mu    sync.Mutex
+}
+
+// Create a FileSessionStore in the directory, it is created when the first session is saved
+func NewFileSessionStore(dir string) *FileSessionStore {
+	return &FileSessionStore{
+		dir:   dir,
+		swept: time.Now(),
+	}
+}
+
+// Get the session of the ID
+func (f *FileSessionStore) Load(token string) (SessionData, error) {
+	if !validSessionID(token) {
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := filepath.Join(f.dir, token)
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return SessionData{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return SessionData{}, err
+	}
+
+	d, err := decodeSessionData(b)
+	if err != nil {
+		return SessionData{}, err
+	}
+	if time.Now().After(d.Expires) {
+		os.Remove(p)
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	return d, nil
+}
+
+// Write the session to its file, returning its ID
+func (f *FileSessionStore) Save(d SessionData) (string, error) {
+	if !validSessionID(d.ID) {
+		return "", ErrSessionNotFound
+	}
+
+	b, err := encodeSessionData(d)
+	if err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err = os.MkdirAll(f.dir, 0o700)
+	if err != nil {
+		return "", err
+	}
+	f.sweep()
+
+	p := filepath.Join(f.dir, d.ID)
+	t, err := os.CreateTemp(f.dir, ".session-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = t.Write(b)
+	if err == nil {
+		err = t.Close()
+	} else {
+		t.Close()
+	}
+	if err == nil {
+		err = os.Chtimes(t.Name(), d.Expires, d.Expires)
+	}
+	if err == nil {
+		err = os.Rename(t.Name(), p)
+	}
+	if err != nil {
+		os.Remove(t.Name())
+		return "", err
+	}
+
+	return d.ID, nil
+}
+
+// Remove the file of the session
+func (f *FileSessionStore) Delete(token string) error {
+	if !validSessionID(token) {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := os.Remove(filepath.Join(f.dir, token))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Remove expired sessions, at most once every SessionSweepInterval
+func (f *FileSessionStore) sweep() {
+	n := time.Now()
+	if n.Sub(f.swept) < SessionSweepInterval {
+		return
+	}
+	f.swept = n
+
+	es, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range es {
+		if e.IsDir() || !validSessionID(e.Name()) {
+			continue
+		}
+
+		i, err := e.Info()
+		if err == nil && n.After(i.ModTime()) {
+			os.Remove(filepath.Join(f.dir, e.Name()))
+		}
+	}
+}
+
+// Keeps sessions in the cookie itself, encrypted with AES-GCM so they can not be read or changed.
+// Nothing is kept on the server, so a session that is deleted or regenerated stays valid until it expires.
+type CookieSessionStore struct {
+	codec cookieCodec
+}
+
+// Create a CookieSessionStore, sessions are encrypted with the first key and any of the keys are accepted
+func NewCookieSessionStore(keys ...[]byte) *CookieSessionStore {
+	return &CookieSessionStore{codec: cookieCodec{keys: keys}}
+}
+
+// Decrypt the session of the cookie
+func (s *CookieSessionStore) Load(token string) (SessionData, error) {
+	v, err := s.codec.decrypt("session", token)
+	if err != nil {
+		return SessionData{}, err
+	}
+
+	d, err := decodeSessionData([]byte(v))
+	if err != nil {
+		return SessionData{}, ErrCookieTampered
+	}
+	if time.Now().After(d.Expires) {
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	return d, nil
+}
+
+// Encrypt the session, returning the value of the cookie
+func (s *CookieSessionStore) Save(d SessionData) (string, error) {
+	b, err := encodeSessionData(d)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := s.codec.encrypt("session", string(b), time.Now())
+	if err != nil {
+		return "", err
+	}
+	if len(t) > maxCookieSize {
+		return "", ErrSessionTooLarge
+	}
+
+	return t, nil
+}
+
+// Nothing is kept on the server, so there is nothing to delete
+func (s *CookieSessionStore) Delete(token string) error {
+	return nil
+}
+
+// Encode a session with gob
+func encodeSessionData(d SessionData) ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := gob.NewEncoder(b).Encode(d)
+	return b.Bytes(), err
+}
+
+// Decode a session encoded with gob
+func decodeSessionData(b []byte) (SessionData, error) {
+	d := SessionData{}
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&d)
+	return d, err
+}
+
+// Copy the values and flashes of a session, so a store does not share them with a request
+func copySessionData(d SessionData) SessionData {
+	d.Values = maps.Clone(d.Values)
+
+	f := make(map[string][]any, len(d.Flashes))
+	for k, v := range d.Flashes {
+		f[k] = slices.Clone(v)
+	}
+	d.Flashes = f
+
+	return d
+}
+
+// Create a random session ID
+func sessionID() string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Is the ID one made by sessionID, so it is safe to use as a file name
+func validSessionID(id string) bool {
+	if len(id) != sessionIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		ok := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
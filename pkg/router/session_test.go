@@ -0,0 +1,185 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSessionStore(t *testing.T, store SessionStore) {
+	app := New()
+	app.Decorate(Sessions(SessionConfig{Store: store, MaxAge: time.Hour}))
+	app.Get("/login", "", func(c *Context) {
+		c.Session().Regenerate()
+		c.Session().Set("user", "routey")
+		c.Session().Flash("notice", "welcome")
+		c.Status(http.StatusNoContent)
+	})
+	app.Get("/user", "", func(c *Context) {
+		u, _ := c.Session().Get("user")
+		n := c.Session().Flashes("notice")
+		c.String(http.StatusOK, "%v %v", u, n)
+	})
+	app.Get("/logout", "", func(c *Context) {
+		c.Session().Clear()
+		c.Status(http.StatusNoContent)
+	})
+	app.Get("/none", "", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := serveRequest(app, http.MethodGet, "/none", "", nil)
+	ck := responseCookie(w, "session")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Nil(t, ck)
+
+	w = serveRequest(app, http.MethodGet, "/login", "", nil)
+	ck = responseCookie(w, "session")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NotNil(t, ck)
+	assert.True(t, ck.HttpOnly)
+	assert.Equal(t, "/", ck.Path)
+	assert.Equal(t, 3600, ck.MaxAge)
+
+	w = serveRequest(app, http.MethodGet, "/user", "", nil, ck)
+	ck2 := responseCookie(w, "session")
+	assert.Equal(t, "routey [welcome]", w.Body.String())
+	assert.NotNil(t, ck2)
+
+	w = serveRequest(app, http.MethodGet, "/user", "", nil, ck2)
+	ck2 = responseCookie(w, "session")
+	assert.Equal(t, "routey []", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/none", "", nil, ck2)
+	ck3 := responseCookie(w, "session")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NotNil(t, ck3, "the session slides on every request")
+
+	w = serveRequest(app, http.MethodGet, "/logout", "", nil, ck3)
+	ck4 := responseCookie(w, "session")
+	assert.NotNil(t, ck4)
+	assert.Equal(t, -1, ck4.MaxAge)
+
+	w = serveRequest(app, http.MethodGet, "/user", "", nil, &http.Cookie{Name: "session", Value: "invalid"})
+	assert.Equal(t, "<nil> []", w.Body.String())
+}
+
+func TestSessionsMemory(t *testing.T) {
+	s := NewMemorySessionStore()
+	testSessionStore(t, s)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSessionsFile(t *testing.T) {
+	d := filepath.Join(t.TempDir(), "sessions")
+	testSessionStore(t, NewFileSessionStore(d))
+
+	es, err := os.ReadDir(d)
+	assert.NoError(t, err)
+	assert.Empty(t, es)
+}
+
+func TestSessionsCookie(t *testing.T) {
+	testSessionStore(t, NewCookieSessionStore([]byte("key")))
+
+	s := NewCookieSessionStore()
+	_, err := s.Save(SessionData{ID: sessionID()})
+	assert.ErrorIs(t, err, ErrNoCookieKeys)
+
+	s = NewCookieSessionStore([]byte("key"))
+	_, err = s.Save(SessionData{ID: sessionID(), Values: map[string]any{"large": string(make([]byte, maxCookieSize))}})
+	assert.ErrorIs(t, err, ErrSessionTooLarge)
+}
+
+func TestSessionRegenerate(t *testing.T) {
+	store := NewMemorySessionStore()
+	app := New()
+	app.Decorate(Sessions(SessionConfig{Store: store}))
+	app.Get("/login", "", func(c *Context) {
+		c.Session().Regenerate()
+		c.Session().Set("user", "routey")
+		c.Session().Flash("notice", "welcome")
+		c.Status(http.StatusNoContent)
+	})
+	app.Get("/user", "", func(c *Context) {
+		u, _ := c.Session().Get("user")
+		n := c.Session().Flashes("notice")
+		c.String(http.StatusOK, "%v %v", u, n)
+	})
+	app.Get("/id", "", func(c *Context) {
+		c.String(http.StatusOK, c.Session().ID())
+	})
+
+	w := serveRequest(app, http.MethodGet, "/login", "", nil)
+	ck := responseCookie(w, "session")
+	w = serveRequest(app, http.MethodGet, "/id", "", nil, ck)
+	id := w.Body.String()
+	assert.Equal(t, ck.Value, id)
+
+	w = serveRequest(app, http.MethodGet, "/login", "", nil, ck)
+	ck2 := responseCookie(w, "session")
+	assert.NotEqual(t, ck.Value, ck2.Value)
+	assert.Equal(t, 1, store.Len())
+
+	w = serveRequest(app, http.MethodGet, "/user", "", nil, ck)
+	assert.Equal(t, "<nil> []", w.Body.String(), "the old ID can not be used")
+
+	w = serveRequest(app, http.MethodGet, "/user", "", nil, ck2)
+	assert.Equal(t, "routey [welcome welcome]", w.Body.String())
+}
+
+func TestSessionExpires(t *testing.T) {
+	i := SessionSweepInterval
+	SessionSweepInterval = 0
+	defer func() {
+		SessionSweepInterval = i
+	}()
+
+	d := t.TempDir()
+	stores := []SessionStore{NewMemorySessionStore(), NewFileSessionStore(d), NewCookieSessionStore([]byte("key"))}
+	for _, s := range stores {
+		id := sessionID()
+		tk, err := s.Save(SessionData{ID: id, Values: map[string]any{"n": 1}, Expires: time.Now().Add(-time.Second)})
+		assert.NoError(t, err)
+
+		_, err = s.Load(tk)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		tk, err = s.Save(SessionData{ID: id, Values: map[string]any{"n": 1}, Expires: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+
+		v, err := s.Load(tk)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v.Values["n"])
+	}
+
+	m := NewMemorySessionStore()
+	m.Save(SessionData{ID: sessionID(), Expires: time.Now().Add(-time.Second)})
+	m.Save(SessionData{ID: sessionID(), Expires: time.Now().Add(time.Hour)})
+	assert.Equal(t, 1, m.Len())
+
+	f := NewFileSessionStore(d)
+	f.Save(SessionData{ID: sessionID(), Expires: time.Now().Add(-time.Second)})
+	f.Save(SessionData{ID: sessionID(), Expires: time.Now().Add(time.Hour)})
+	es, _ := os.ReadDir(d)
+	assert.Len(t, es, 2)
+}
+
+func TestFileSessionStoreID(t *testing.T) {
+	f := NewFileSessionStore(t.TempDir())
+
+	_, err := f.Load("../../etc/passwd")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	_, err = f.Save(SessionData{ID: "../session"})
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestContextSession(t *testing.T) {
+	c, _ := cookieContext(New())
+	assert.Nil(t, c.Session())
+}
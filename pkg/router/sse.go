@@ -0,0 +1,253 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sseContentType = []string{"text/event-stream"}
+
+// How often a keep-alive comment is sent while streaming Server-Sent Events, unless configured
+const DefaultKeepAliveInterval = 15 * time.Second
+
+// Configure Context.Stream
+//
+//   - KeepAliveInterval: how often a keep-alive comment is sent while streaming Server-Sent Events,
+//     defaults to DefaultKeepAliveInterval, below 0 sends none
+type StreamConfig struct {
+	KeepAliveInterval time.Duration
+}
+
+// A Server-Sent Event
+//
+//   - Event: the name of the event, clients listen for message when empty
+//
+//   - ID: the id of the event, sent back by the client in the Last-Event-ID header
+//
+//   - Retry: how long the client should wait before reconnecting
+//
+//   - Data: the data of the event, strings and bytes are sent as is, anything else is encoded as JSON
+type SSEvent struct {
+	Event string
+	ID    string
+	Retry time.Duration
+	Data  any
+}
+
+// Render the event
+func (e SSEvent) Render(w http.ResponseWriter) error {
+	d, err := e.data()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + sseClean(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + sseClean(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	for _, l := range strings.Split(d, "\n") {
+		b.WriteString("data: " + strings.TrimSuffix(l, "\r") + "\n")
+	}
+	b.WriteString("\n")
+
+	e.WriteContentType(w)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// Write the content type
+func (e SSEvent) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, sseContentType)
+}
+
+// Get the data as a string
+func (e SSEvent) data() (string, error) {
+	switch d := e.Data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return d, nil
+	case []byte:
+		return string(d), nil
+	}
+
+	b, err := json.Marshal(e.Data)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal %T to json: %w", e.Data, err)
+	}
+
+	return string(b), nil
+}
+
+// Remove newlines, these would end the field early
+func sseClean(s string) string {
+	return strings.NewReplacer("\n", "", "\r", "").Replace(s)
+}
+
+// Send a Server-Sent Event with a name and data, then flush it to the client
+func (c *Context) SSEvent(n string, d any) {
+	c.SSE(SSEvent{Event: n, Data: d})
+}
+
+// Send a Server-Sent Event, then flush it to the client
+func (c *Context) SSE(e SSEvent) {
+	h := c.writer.Header()
+	if h.Get("Content-Type") == "" {
+		e.WriteContentType(c.writer)
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+	}
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	err := e.Render(c.writer)
+	if err != nil {
+		logError(c.logger(), fmt.Sprintf("%T: %s", e, err.Error()), "RENDER")
+		c.Abort()
+		return
+	}
+
+	c.Flush()
+}
+
+// Get the Last-Event-ID sent by a reconnecting client, used to resume a stream of events
+func (c *Context) LastEventID() string {
+	return c.GetHeader("Last-Event-ID")
+}
+
+// Flush any buffered data to the client
+func (c *Context) Flush() {
+	err := http.NewResponseController(c.writer).Flush()
+	if err != nil {
+		logError(c.logger(), err.Error(), "FLUSH")
+	}
+}
+
+// Stream calls step and flushes until step returns false or the client disconnects.
+// Returns true if the client disconnected.
+//
+// The client disconnecting is only noticed between steps, so a step that waits,
+// such as for a value from a channel, must also select on Context.Done and return.
+//
+// A keep-alive comment is sent every KeepAliveInterval of the StreamConfig while streaming Server-Sent Events.
+func (c *Context) Stream(step func(w io.Writer) bool, cfg ...StreamConfig) bool {
+	k := DefaultKeepAliveInterval
+	if len(cfg) > 0 && cfg[0].KeepAliveInterval != 0 {
+		k = cfg[0].KeepAliveInterval
+	}
+
+	ctx := c.request.Context()
+
+	w := &streamWriter{ResponseWriter: c.writer}
+	o := c.writer
+	c.writer = w
+	defer func() {
+		c.writer = o
+	}()
+
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.keepAlive(k, stop)
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		default:
+		}
+
+		if !step(w) {
+			return ctx.Err() != nil
+		}
+		w.Flush()
+	}
+}
+
+// Get a channel that is closed when the client disconnects or the request is cancelled
+func (c *Context) Done() <-chan struct{} {
+	return c.request.Context().Done()
+}
+
+// Serializes writes while streaming, so keep-alive comments can be sent between steps
+type streamWriter struct {
+	http.ResponseWriter
+	mu  sync.Mutex
+	sse bool
+}
+
+// Write the status
+func (s *streamWriter) WriteHeader(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ResponseWriter.WriteHeader(i)
+}
+
+// Write the body, noting if events are being streamed
+func (s *streamWriter) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.sse {
+		s.sse = s.ResponseWriter.Header().Get("Content-Type") == sseContentType[0]
+	}
+
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush the underlying writer
+func (s *streamWriter) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	http.NewResponseController(s.ResponseWriter).Flush()
+}
+
+// Get the underlying writer, used by http.ResponseController
+func (s *streamWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Send keep-alive comments every interval until stopped, only when streaming events
+func (s *streamWriter) keepAlive(i time.Duration, stop chan struct{}) {
+	if i <= 0 {
+		return
+	}
+
+	t := time.NewTicker(i)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			s.mu.Lock()
+			if s.sse {
+				io.WriteString(s.ResponseWriter, ": keep-alive\n\n")
+				http.NewResponseController(s.ResponseWriter).Flush()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSEventRender(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := SSEvent{
+		Event: "progress",
+		ID:    "1",
+		Retry: time.Second,
+		Data:  M{"done": 50},
+	}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 1\nevent: progress\nretry: 1000\ndata: {\"done\":50}\n\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	err = SSEvent{Data: "one\ntwo"}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "data: one\ndata: two\n\n", w.Body.String())
+}
+
+func TestContextSSEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.SSEvent("message", "hello")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Connection"), "Connection is hop-by-hop and not allowed over HTTP/2")
+	assert.Equal(t, "event: message\ndata: hello\n\n", w.Body.String())
+	assert.True(t, w.Flushed)
+}
+
+func TestContextLastEventID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Last-Event-ID", "42")
+	c := Context{request: r}
+	assert.Equal(t, "42", c.LastEventID())
+}
+
+func TestContextStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+
+	i := 0
+	gone := c.Stream(func(w io.Writer) bool {
+		c.SSE(SSEvent{ID: "id", Data: i})
+		i++
+		return i < 3
+	})
+	assert.False(t, gone)
+	assert.Equal(t, "id: id\ndata: 0\n\nid: id\ndata: 1\n\nid: id\ndata: 2\n\n", w.Body.String())
+	assert.Equal(t, w, c.writer)
+}
+
+func TestContextStreamDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: r}
+
+	gone := c.Stream(func(w io.Writer) bool {
+		cancel()
+		return true
+	})
+	assert.True(t, gone)
+}
+
+// Signals when a keep-alive comment is written
+type keepAliveRecorder struct {
+	*httptest.ResponseRecorder
+	sent chan struct{}
+}
+
+func (k *keepAliveRecorder) Write(b []byte) (int, error) {
+	if string(b) == ": keep-alive\n\n" {
+		select {
+		case k.sent <- struct{}{}:
+		default:
+		}
+	}
+
+	return k.ResponseRecorder.Write(b)
+}
+
+func (k *keepAliveRecorder) WriteString(s string) (int, error) {
+	return k.Write([]byte(s))
+}
+
+func TestContextStreamKeepAlive(t *testing.T) {
+	k := &keepAliveRecorder{ResponseRecorder: httptest.NewRecorder(), sent: make(chan struct{}, 1)}
+	c := Context{writer: k, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+
+	c.Stream(func(w io.Writer) bool {
+		c.SSEvent("", 0)
+		<-k.sent
+		return false
+	}, StreamConfig{KeepAliveInterval: time.Millisecond})
+	assert.Contains(t, k.Body.String(), "data: 0\n\n: keep-alive\n\n")
+
+	sw := &streamWriter{ResponseWriter: httptest.NewRecorder(), sse: true}
+	sw.keepAlive(-1, nil)
+	assert.Empty(t, sw.ResponseWriter.(*httptest.ResponseRecorder).Body.String(), "a negative interval returns without sending")
+}
+
+func TestContextStreamBlockingStep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := Context{writer: httptest.NewRecorder(), request: r}
+
+	values := make(chan int)
+	go cancel()
+	gone := c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Done():
+			return false
+		case v := <-values:
+			c.SSEvent("", v)
+			return true
+		}
+	})
+	assert.True(t, gone, "a step waiting on a channel stops when the client disconnects")
+}
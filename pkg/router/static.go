@@ -0,0 +1,437 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The length of the content hash used in fingerprinted file names
+const fingerprintLength = 8
+
+// Configure how static files are served
+//
+//   - Index: the file served for a directory, defaults to index.html
+//
+//   - Browse: list the contents of directories without an index file
+//
+//   - SPA: serve the root index file for paths without an extension that do not exist, for single page apps
+//
+//   - Precompressed: serve file.br or file.gz in place of file when the client accepts them
+//
+//   - Fingerprint: serve app.3f9a1c2b.js from app.js when the hash matches, with an immutable Cache-Control
+//
+//   - MaxAge: the max-age of files that are not fingerprinted, 0 does not set a Cache-Control
+type StaticConfig struct {
+	Index         string
+	Browse        bool
+	SPA           bool
+	Precompressed bool
+	Fingerprint   bool
+	MaxAge        time.Duration
+}
+
+// Serves the files of a fs.FS under a prefix
+//
+//   - prefix: the path the files are served under
+//
+//   - fsys: the files being served
+//
+//   - cfg: the StaticConfig
+//
+//   - hashes: a cache of the content hashes of files
+type staticServer struct {
+	prefix string
+	fsys   fs.FS
+	cfg    StaticConfig
+
+	hashes map[string]staticHash
+	mu     sync.Mutex
+}
+
+// The content hash of a file, reused until the file changes
+type staticHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// An encoding that can be served from a sibling file
+type staticEncoding struct {
+	name string
+	ext  string
+}
+
+// Encodings that are looked for, in order of preference
+var staticEncodings = []staticEncoding{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+// Serve the files of a directory under the prefix
+func (a *App) Static(prefix string, dir string, cfg ...StaticConfig) {
+	a.StaticFS(prefix, os.DirFS(dir), cfg...)
+}
+
+// Serve the files of a fs.FS under the prefix, use fs.Sub to serve a sub directory of an embed.FS
+func (a *App) StaticFS(prefix string, fsys fs.FS, cfg ...StaticConfig) {
+	o := StaticConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+	if o.Index == "" {
+		o.Index = "index.html"
+	}
+
+	s := &staticServer{
+		prefix: strings.TrimSuffix(prefix, "/"),
+		fsys:   fsys,
+		cfg:    o,
+		hashes: make(map[string]staticHash),
+	}
+	a.statics = append(a.statics, s)
+
+	a.Get(s.prefix, "/*filepath", s.Serve)
+	a.Head(s.prefix, "/*filepath", s.Serve)
+}
+
+// Serve a single file at the path
+func (a *App) StaticFile(path string, file string) {
+	h := func(c *Context) {
+		c.GetFile(file)
+	}
+
+	a.Get(path, "", h)
+	a.Head(path, "", h)
+}
+
+// Get the fingerprinted path of a static file, used by the asset template function.
+// /app.js becomes /app.3f9a1c2b.js, the path is returned as is if it is not served with Fingerprint.
+func (a *App) Asset(p string) string {
+	for i := len(a.statics) - 1; i >= 0; i-- {
+		s := a.statics[i]
+		if !s.cfg.Fingerprint {
+			continue
+		}
+
+		n, ok := strings.CutPrefix(p, s.prefix+"/")
+		if !ok {
+			continue
+		}
+
+		h, err := s.hash(n)
+		if err != nil {
+			continue
+		}
+
+		return s.prefix + "/" + fingerprintName(n, h)
+	}
+
+	return p
+}
+
+// Serve the requested file
+func (s *staticServer) Serve(c *Context) {
+	p, _ := c.Param("filepath")
+
+	n, ok := staticPath(p)
+	if !ok {
+		c.HandleError(http.StatusBadRequest, errors.New("invalid path"))
+		return
+	}
+
+	i, err := fs.Stat(s.fsys, n)
+	if err != nil {
+		if o, ok := s.fingerprinted(n); ok {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+			s.serveFile(c, o)
+			return
+		}
+
+		if s.cfg.SPA && errors.Is(err, fs.ErrNotExist) && path.Ext(n) == "" {
+			s.serveFile(c, s.cfg.Index)
+			return
+		}
+
+		c.HandleError(staticStatus(err), err)
+		return
+	}
+
+	if !i.IsDir() {
+		s.serveFile(c, n)
+		return
+	}
+
+	if n != "." && !strings.HasSuffix(c.request.URL.Path, "/") {
+		u := *c.request.URL
+		u.Path += "/"
+		c.Redirect(http.StatusMovedPermanently, u.String())
+		return
+	}
+
+	x := path.Join(n, s.cfg.Index)
+	_, err = fs.Stat(s.fsys, x)
+	if err == nil {
+		s.serveFile(c, x)
+		return
+	}
+
+	if s.cfg.Browse {
+		s.serveDir(c, n)
+		return
+	}
+
+	c.HandleError(http.StatusNotFound, fs.ErrNotExist)
+}
+
+// Get the original name of a fingerprinted name, if its hash matches the file
+func (s *staticServer) fingerprinted(n string) (string, bool) {
+	if !s.cfg.Fingerprint {
+		return "", false
+	}
+
+	e := path.Ext(n)
+	b := strings.TrimSuffix(n, e)
+	d := strings.LastIndex(b, ".")
+	if d < 0 || len(b)-d-1 != fingerprintLength {
+		return "", false
+	}
+
+	o := b[:d] + e
+	h, err := s.hash(o)
+	if err != nil || h[:fingerprintLength] != b[d+1:] {
+		return "", false
+	}
+
+	return o, true
+}
+
+// Get the content hash of a file, cached until the file changes
+func (s *staticServer) hash(n string) (string, error) {
+	i, err := fs.Stat(s.fsys, n)
+	if err != nil {
+		return "", err
+	}
+	if i.IsDir() {
+		return "", fs.ErrNotExist
+	}
+
+	s.mu.Lock()
+	h, ok := s.hashes[n]
+	s.mu.Unlock()
+	if ok && h.size == i.Size() && h.modTime.Equal(i.ModTime()) {
+		return h.hash, nil
+	}
+
+	f, err := s.fsys.Open(n)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	d := sha256.New()
+	_, err = io.Copy(d, f)
+	if err != nil {
+		return "", err
+	}
+
+	h = staticHash{
+		size:    i.Size(),
+		modTime: i.ModTime(),
+		hash:    hex.EncodeToString(d.Sum(nil)),
+	}
+
+	s.mu.Lock()
+	s.hashes[n] = h
+	s.mu.Unlock()
+
+	return h.hash, nil
+}
+
+// Serve a single file, handling precompressed siblings, ranges and conditional requests
+func (s *staticServer) serveFile(c *Context, n string) {
+	f := n
+
+	if s.cfg.Precompressed {
+		addVary(c.writer.Header(), "Accept-Encoding")
+
+		for _, e := range staticEncodings {
+			if !acceptsEncoding(c.request, e.name) {
+				continue
+			}
+
+			_, err := fs.Stat(s.fsys, n+e.ext)
+			if err != nil {
+				continue
+			}
+
+			f = n + e.ext
+			c.Header("Content-Encoding", e.name)
+			break
+		}
+	}
+
+	if c.writer.Header().Get("Cache-Control") == "" && s.cfg.MaxAge > 0 {
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(s.cfg.MaxAge.Seconds())))
+	}
+
+	h, err := s.hash(f)
+	if err == nil {
+		c.Header("ETag", `"`+h[:2*fingerprintLength]+`"`)
+	}
+
+	t := mime.TypeByExtension(path.Ext(n))
+	if t != "" {
+		c.Header("Content-Type", t)
+	}
+
+	serveStaticFile(c, s.fsys, f, path.Base(n))
+}
+
+// Serve a listing of the directory
+func (s *staticServer) serveDir(c *Context, n string) {
+	es, err := fs.ReadDir(s.fsys, n)
+	if err != nil {
+		c.HandleError(staticStatus(err), err)
+		return
+	}
+
+	sort.Slice(es, func(i, j int) bool {
+		return es[i].Name() < es[j].Name()
+	})
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<body>\n<pre>\n")
+	for _, e := range es {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+
+		u := url.URL{Path: "./" + name}
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n", html.EscapeString(u.String()), html.EscapeString(name))
+	}
+	b.WriteString("</pre>\n</body>\n</html>\n")
+
+	writeContentType(c.writer, htmlContentType)
+	c.Render(http.StatusOK, b.String())
+}
+
+// Clean the requested path into a name that can be opened in a fs.FS, rejecting traversal
+func staticPath(p string) (string, bool) {
+	if strings.ContainsAny(p, "\\\x00") {
+		return "", false
+	}
+
+	for _, s := range strings.Split(p, "/") {
+		if s == ".." {
+			return "", false
+		}
+	}
+
+	n := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if n == "" {
+		n = "."
+	}
+
+	return n, fs.ValidPath(n)
+}
+
+// Get the status for an error opening a file
+func staticStatus(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Serve a single file from the fs.FS as the given name, handling ranges and conditional requests
+func serveStaticFile(c *Context, fsys fs.FS, n string, name string) {
+	f, err := fsys.Open(n)
+	if err != nil {
+		c.HandleError(staticStatus(err), err)
+		return
+	}
+	defer f.Close()
+
+	i, err := f.Stat()
+	if err != nil || i.IsDir() {
+		c.HandleError(http.StatusNotFound, fs.ErrNotExist)
+		return
+	}
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			c.HandleError(http.StatusInternalServerError, err)
+			return
+		}
+		rs = bytes.NewReader(b)
+	}
+
+	c.serveContent(name, i.ModTime(), rs)
+}
+
+// Serve the content with http.ServeContent, keeping the status it responds with such as 206, 304 or 416
+func (c *Context) serveContent(name string, t time.Time, rs io.ReadSeeker) {
+	w := &statusWriter{ResponseWriter: c.writer}
+	http.ServeContent(w, c.request, name, t, rs)
+
+	c.status = w.status
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+}
+
+// Records the status written to the ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// Write the status, keeping the first
+func (w *statusWriter) WriteHeader(s int) {
+	if w.status == 0 {
+		w.status = s
+	}
+	w.ResponseWriter.WriteHeader(s)
+}
+
+// Write the body, which writes a 200 if no status has been written
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Get the underlying ResponseWriter, for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Get the fingerprinted name of a file, app.js becomes app.3f9a1c2b.js
+func fingerprintName(n string, h string) string {
+	e := path.Ext(n)
+	return strings.TrimSuffix(n, e) + "." + h[:fingerprintLength] + e
+}
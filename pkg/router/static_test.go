@@ -0,0 +1,205 @@
+package router
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var staticMockFS = fstest.MapFS{
+	"index.html":        {Data: []byte("<h1>index</h1>")},
+	"app.js":            {Data: []byte("console.log('routey')")},
+	"docs/guide.txt":    {Data: []byte("guide")},
+	"assets/index.html": {Data: []byte("<h1>assets</h1>")},
+}
+
+func TestStaticFS(t *testing.T) {
+	app := New()
+	app.StaticFS("/static", staticMockFS)
+
+	w := serveRequest(app, http.MethodGet, "/static/app.js", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "console.log('routey')", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+
+	w = serveRequest(app, http.MethodGet, "/static", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>index</h1>", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/static/assets/", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>assets</h1>", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/static/assets", "", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/static/assets/", w.Header().Get("Location"))
+
+	w = serveRequest(app, http.MethodHead, "/static/app.js", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/static/missing.js", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveRequest(app, http.MethodGet, "/static/docs/", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStaticFSBrowse(t *testing.T) {
+	app := New()
+	app.StaticFS("/static", staticMockFS, StaticConfig{Browse: true})
+
+	w := serveRequest(app, http.MethodGet, "/static/docs/", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<a href="./guide.txt">guide.txt</a>`)
+}
+
+func TestStaticFSSPA(t *testing.T) {
+	app := New()
+	app.StaticFS("/", staticMockFS, StaticConfig{SPA: true})
+
+	w := serveRequest(app, http.MethodGet, "/users/4", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>index</h1>", w.Body.String())
+
+	w = serveRequest(app, http.MethodGet, "/missing.js", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStaticTraversal(t *testing.T) {
+	d := t.TempDir()
+	os.WriteFile(filepath.Join(d, "secret.txt"), []byte("secret"), 0600)
+	os.Mkdir(filepath.Join(d, "public"), 0750)
+	os.WriteFile(filepath.Join(d, "public", "file.txt"), []byte("public"), 0600)
+
+	app := New()
+	app.Static("/static", filepath.Join(d, "public"))
+
+	w := serveRequest(app, http.MethodGet, "/static/file.txt", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public", w.Body.String())
+
+	for _, p := range []string{"/static/../secret.txt", "/static/%2e%2e/secret.txt", "/static/..%5csecret.txt"} {
+		w = serveRequest(app, http.MethodGet, p, "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, p)
+		assert.NotContains(t, w.Body.String(), "secret", p)
+	}
+}
+
+func TestStaticFile(t *testing.T) {
+	d := t.TempDir()
+	f := filepath.Join(d, "robots.txt")
+	os.WriteFile(f, []byte("User-agent: *"), 0600)
+
+	app := New()
+	app.StaticFile("/robots.txt", f)
+
+	w := serveRequest(app, http.MethodGet, "/robots.txt", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "User-agent: *", w.Body.String())
+}
+
+func TestStaticPath(t *testing.T) {
+	n, ok := staticPath("")
+	assert.True(t, ok)
+	assert.Equal(t, ".", n)
+
+	n, ok = staticPath("a//b/./c")
+	assert.True(t, ok)
+	assert.Equal(t, "a/b/c", n)
+
+	_, ok = staticPath("a/../../b")
+	assert.False(t, ok)
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	app := New()
+	app.StaticFS("/static", fstest.MapFS{
+		"app.js":    {Data: []byte("console.log('routey')")},
+		"app.js.br": {Data: []byte("brotli")},
+		"app.js.gz": {Data: []byte("gzip")},
+		"app.css":   {Data: []byte("body{}")},
+	}, StaticConfig{Precompressed: true})
+
+	cases := map[string]struct {
+		encoding string
+		body     string
+	}{
+		"":                  {"", "console.log('routey')"},
+		"gzip":              {"gzip", "gzip"},
+		"gzip, br":          {"br", "brotli"},
+		"br;q=0, gzip;q=.5": {"gzip", "gzip"},
+		"identity":          {"", "console.log('routey')"},
+	}
+
+	for accept, c := range cases {
+		w := serveRequest(app, http.MethodGet, "/static/app.js", "", map[string]string{"Accept-Encoding": accept})
+
+		assert.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Equal(t, c.encoding, w.Header().Get("Content-Encoding"), accept)
+		assert.Equal(t, c.body, w.Body.String(), accept)
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript", accept)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), accept)
+	}
+
+	w := serveRequest(app, http.MethodGet, "/static/app.css", "", map[string]string{"Accept-Encoding": "gzip, br"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "body{}", w.Body.String())
+}
+
+func TestStaticETag(t *testing.T) {
+	status := 0
+	app := New()
+	app.Decorate(func(f HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			f(c)
+			status = c.status
+		}
+	})
+	app.StaticFS("/static", staticMockFS, StaticConfig{MaxAge: time.Hour})
+
+	w := serveRequest(app, http.MethodGet, "/static/app.js", "", nil)
+	e := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{16}"$`, e)
+	assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+
+	w = serveRequest(app, http.MethodGet, "/static/app.js", "", map[string]string{"If-None-Match": e})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, http.StatusNotModified, status, "the status of the Context is the status ServeContent wrote")
+	assert.Empty(t, w.Body.String())
+}
+
+func TestStaticFingerprint(t *testing.T) {
+	app := New()
+	app.StaticFS("/static", staticMockFS, StaticConfig{Fingerprint: true})
+
+	p := app.Asset("/static/app.js")
+	assert.Regexp(t, `^/static/app\.[0-9a-f]{8}\.js$`, p)
+	assert.Equal(t, "/static/missing.js", app.Asset("/static/missing.js"))
+	assert.Equal(t, "/other/app.js", app.Asset("/other/app.js"))
+
+	w := serveRequest(app, http.MethodGet, p, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "console.log('routey')", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+
+	w = serveRequest(app, http.MethodGet, "/static/app.js", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+
+	w = serveRequest(app, http.MethodGet, "/static/app.00000000.js", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	tmpl := template.Must(template.New("").Funcs(app.funcMap).Parse(`{{asset "/static/app.js"}}`))
+	b := &strings.Builder{}
+	assert.NoError(t, tmpl.Execute(b, nil))
+	assert.Equal(t, p, b.String())
+}
@@ -0,0 +1,185 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+)
+
+// JSON array renderer, encodes each element of the sequence as it is produced and flushes it.
+//
+//   - Data: the elements, use slices.Values for a slice or ChanSeq for a channel
+//
+//   - Context: stops the stream when done, normally the request's context
+type JSONStream struct {
+	Data    iter.Seq[any]
+	Context context.Context
+}
+
+// Newline delimited JSON renderer, flushes after each element of the sequence.
+//
+//   - Data: the elements, use slices.Values for a slice or ChanSeq for a channel
+//
+//   - Context: stops the stream when done, normally the request's context
+type NDJSON struct {
+	Data    iter.Seq[any]
+	Context context.Context
+}
+
+// Render the JSON array
+func (j JSONStream) Render(w http.ResponseWriter) error {
+	f, _ := w.(http.Flusher)
+	i := 0
+
+	return streamSeq(j.Context, j.Data, func(v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("unable to marshal %T to json: %w", v, err)
+		}
+
+		p := []byte{','}
+		if i == 0 {
+			j.WriteContentType(w)
+			p[0] = '['
+		}
+		i++
+
+		_, err = w.Write(append(p, b...))
+		if err != nil {
+			return err
+		}
+
+		if f != nil {
+			f.Flush()
+		}
+		return nil
+	}, func() error {
+		if i == 0 {
+			j.WriteContentType(w)
+			_, err := w.Write([]byte("[]"))
+			return err
+		}
+
+		_, err := w.Write([]byte("]"))
+		return err
+	})
+}
+
+// Write the content type
+func (j JSONStream) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render the newline delimited JSON
+func (n NDJSON) Render(w http.ResponseWriter) error {
+	f, _ := w.(http.Flusher)
+
+	return streamSeq(n.Context, n.Data, func(v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("unable to marshal %T to json: %w", v, err)
+		}
+
+		n.WriteContentType(w)
+		_, err = w.Write(append(b, '\n'))
+		if err != nil {
+			return err
+		}
+
+		if f != nil {
+			f.Flush()
+		}
+		return nil
+	}, func() error {
+		n.WriteContentType(w)
+		return nil
+	})
+}
+
+// Write the content type
+func (n NDJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, ndjsonContentType)
+}
+
+// Render a sequence as a JSON array, encoding and flushing each element as it is produced.
+// The stream stops when the client disconnects.
+func (c *Context) JSONStream(s int, seq iter.Seq[any]) {
+	c.RenderWith(s, JSONStream{Data: seq, Context: c.request.Context()})
+}
+
+// Render a sequence as newline delimited JSON, flushing after each element.
+// The stream stops when the client disconnects.
+func (c *Context) NDJSON(s int, seq iter.Seq[any]) {
+	c.RenderWith(s, NDJSON{Data: seq, Context: c.request.Context()})
+}
+
+// Render a typed sequence as a JSON array with Context.JSONStream
+func StreamJSON[T any](c *Context, s int, seq iter.Seq[T]) {
+	c.JSONStream(s, anySeq(seq))
+}
+
+// Render a typed sequence as newline delimited JSON with Context.NDJSON
+func StreamNDJSON[T any](c *Context, s int, seq iter.Seq[T]) {
+	c.NDJSON(s, anySeq(seq))
+}
+
+// Get a sequence of the values received from the channel, until it is closed or ctx is done
+func ChanSeq[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Convert a sequence to a sequence of any, for the Data of a stream renderer
+func anySeq[T any](seq iter.Seq[T]) iter.Seq[any] {
+	if seq == nil {
+		return nil
+	}
+
+	return func(yield func(any) bool) {
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Call f with each element of the sequence, then done once it has finished
+func streamSeq(ctx context.Context, seq iter.Seq[any], f func(any) error, done func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var err error
+	if seq != nil {
+		for v := range seq {
+			err = ctx.Err()
+			if err == nil {
+				err = f(v)
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	return done()
+}
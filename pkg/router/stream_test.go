@@ -0,0 +1,121 @@
+package router
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamSeqMock(n int) iter.Seq[M] {
+	return func(yield func(M) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(M{"id": i}) {
+				return
+			}
+		}
+	}
+}
+
+// Records the body each time it is flushed
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.ResponseRecorder.Flush()
+	f.flushed = append(f.flushed, f.Body.String())
+}
+
+func TestStreamJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	StreamJSON(&c, http.StatusOK, streamSeqMock(3))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"id":0},{"id":1},{"id":2}]`, w.Body.String())
+	assert.True(t, w.Flushed)
+
+	w = httptest.NewRecorder()
+	c = Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	StreamJSON(&c, http.StatusOK, streamSeqMock(0))
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestContextJSONStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.JSONStream(http.StatusOK, slices.Values([]any{"a", 1, true}))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `["a",1,true]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	c = Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	c.NDJSON(http.StatusOK, slices.Values([]any{"a", 1}))
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, "\"a\"\n1\n", w.Body.String())
+}
+
+func TestStreamJSONFlush(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	err := JSONStream{Data: anySeq(slices.Values([]int{1, 2}))}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"[1", "[1,2"}, w.flushed, "each element is flushed as it is written")
+	assert.Equal(t, "[1,2]", w.Body.String())
+}
+
+func TestStreamJSONChannel(t *testing.T) {
+	ch := make(chan int)
+	go func() {
+		for i := 0; i < 3; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	StreamJSON(&c, http.StatusOK, ChanSeq(c.request.Context(), ch))
+	assert.Equal(t, "[0,1,2]", w.Body.String())
+}
+
+func TestStreamJSONError(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	StreamJSON(&c, http.StatusOK, slices.Values([]any{make(chan int)}))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := Context{writer: w, request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	StreamNDJSON(&c, http.StatusOK, slices.Values([]M{{"id": 0}, {"id": 1}}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"id\":0}\n{\"id\":1}\n", w.Body.String())
+	assert.True(t, w.Flushed)
+}
+
+func TestNDJSONCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	seq := func(yield func(any) bool) {
+		if yield(1) {
+			cancel()
+			yield(2)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	err := NDJSON{Data: seq, Context: ctx}.Render(w)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "1\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	err = NDJSON{Data: anySeq(ChanSeq(ctx, make(chan int))), Context: ctx}.Render(w)
+	assert.ErrorIs(t, err, context.Canceled, "a channel that is never sent to stops with the context")
+}
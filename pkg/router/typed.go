@@ -0,0 +1,141 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/joseph-beck/routey/pkg/binding"
+	"google.golang.org/protobuf/proto"
+)
+
+// A typed handler, takes the bound input and returns the output to render
+type TypedFunc[In any, Out any] func(c *Context, in In) (Out, error)
+
+// An error that carries the status it should be responded with
+//
+//   - Code: the http status of the error
+//
+//   - Err: the underlying error
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+// Create a new StatusError with a status and an error
+func NewStatusError(s int, err error) *StatusError {
+	return &StatusError{
+		Code: s,
+		Err:  err,
+	}
+}
+
+// Get the message of the StatusError
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+// Unwrap the underlying error
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// Adapt a TypedFunc into a HandlerFunc.
+//
+// In is bound from the path params (uri tag), query (query tag), headers (header tag)
+// and then the body, the binder is chosen using the Content-Type of the request.
+// Validation is ran once all of these have been bound.
+// Out is rendered with a 200 using the format negotiated from the Accept header,
+// the handler is not called when none of the formats are acceptable.
+// Errors are rendered in the negotiated format, or as JSON when none are acceptable.
+func Typed[In any, Out any](f TypedFunc[In, Out]) HandlerFunc {
+	o := defaultOffered
+	var zero Out
+	if _, ok := any(zero).(proto.Message); ok {
+		o = append(o[:len(o):len(o)], MIMEProtoBuf)
+	}
+
+	return func(c *Context) {
+		if c.NegotiateFormat(o...) == "" {
+			c.renderTypedError(NewStatusError(http.StatusNotAcceptable, nil))
+			return
+		}
+
+		var in In
+
+		err := c.bindTyped(&in)
+		if err != nil {
+			var se *StatusError
+			if !errors.As(err, &se) {
+				se = NewStatusError(bindStatus(err), err)
+			}
+			c.renderTypedError(se)
+			return
+		}
+
+		out, err := f(c, in)
+		if err != nil {
+			c.renderTypedError(err)
+			return
+		}
+
+		c.Negotiate(http.StatusOK, Negotiation{Offered: o, Data: out})
+	}
+}
+
+// Bind all parts of the request to a
+func (c *Context) bindTyped(a any) error {
+	err := binding.MapValues(a, c.paramValues(), "uri")
+	if err != nil {
+		return err
+	}
+
+	err = binding.MapValues(a, c.request.URL.Query(), "query")
+	if err != nil {
+		return err
+	}
+
+	err = binding.MapHeader(a, c.request.Header)
+	if err != nil {
+		return err
+	}
+
+	if !hasBody(c.request) {
+		if binding.Validator == nil {
+			return nil
+		}
+		return binding.Validator.ValidateStruct(a)
+	}
+
+	b, err := binding.Default(c.request.Method, c.GetHeader("Content-Type"))
+	if err != nil {
+		return NewStatusError(http.StatusUnsupportedMediaType, err)
+	}
+
+	return c.ShouldBindWith(a, b)
+}
+
+// Render an error returned from a typed handler, as JSON when none of the formats are acceptable
+func (c *Context) renderTypedError(err error) {
+	var se *StatusError
+	if !errors.As(err, &se) {
+		se = NewStatusError(http.StatusInternalServerError, nil)
+	}
+
+	m := c.NegotiateFormat(defaultOffered...)
+	if m == "" {
+		m = MIMEJSON
+	}
+
+	c.Abort()
+	addVary(c.writer.Header(), "Accept")
+	c.renderFormat(se.Code, m, Negotiation{Data: M{"error": se.Error()}})
+}
+
+// Does the request have a body?
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
@@ -0,0 +1,135 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type typedIn struct {
+	ID    int    `uri:"id"`
+	Page  int    `query:"page"`
+	Token string `header:"X-Token"`
+	Name  string `json:"name" xml:"name" binding:"required"`
+}
+
+type typedOut struct {
+	ID    int    `json:"id" xml:"id"`
+	Page  int    `json:"page" xml:"page"`
+	Token string `json:"token" xml:"token"`
+	Name  string `json:"name" xml:"name"`
+}
+
+func typedHandler() HandlerFunc {
+	return Typed(func(c *Context, in typedIn) (typedOut, error) {
+		if in.ID == 0 {
+			return typedOut{}, NewStatusError(http.StatusNotFound, errors.New("not found"))
+		}
+		if in.ID < 0 {
+			return typedOut{}, errors.New("secret")
+		}
+
+		return typedOut(in), nil
+	})
+}
+
+func TestTyped(t *testing.T) {
+	app := New()
+	app.Post("/typed", "/:id", typedHandler())
+
+	r := httptest.NewRequest(http.MethodPost, "/typed/3?page=2", strings.NewReader(`{"name":"routey"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "abc")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":3,"page":2,"token":"abc","name":"routey"}`, w.Body.String())
+}
+
+func TestTypedXML(t *testing.T) {
+	app := New()
+	app.Post("/typed", "/:id", typedHandler())
+
+	r := httptest.NewRequest(http.MethodPost, "/typed/3", strings.NewReader(`<typedIn><name>routey</name></typedIn>`))
+	r.Header.Set("Content-Type", "application/xml")
+	r.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<name>routey</name>")
+}
+
+func TestTypedBindError(t *testing.T) {
+	app := New()
+	app.Post("/typed", "/:id", typedHandler())
+
+	r := httptest.NewRequest(http.MethodPost, "/typed/3", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	r = httptest.NewRequest(http.MethodPost, "/typed/3", strings.NewReader(`name: routey`))
+	r.Header.Set("Content-Type", "application/unknown")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestTypedError(t *testing.T) {
+	app := New()
+	app.Post("/typed", "/:id", typedHandler())
+
+	r := httptest.NewRequest(http.MethodPost, "/typed/0", strings.NewReader(`{"name":"routey"}`))
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+}
+
+func TestTypedNotAcceptable(t *testing.T) {
+	called := false
+	app := New()
+	app.Post("/typed", "/:id", Typed(func(c *Context, in typedIn) (typedOut, error) {
+		called = true
+		return typedOut(in), nil
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/typed/3", strings.NewReader(`{"name":"routey"}`))
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.JSONEq(t, `{"error":"Not Acceptable"}`, w.Body.String())
+	assert.False(t, called, "the handler is not called when its output can not be rendered")
+}
+
+func TestTypedErrorNotAcceptable(t *testing.T) {
+	app := New()
+	app.Get("/proto", "", Typed(func(c *Context, in struct{}) (*wrapperspb.StringValue, error) {
+		return nil, NewStatusError(http.StatusNotFound, errors.New("not found"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/proto", nil)
+	r.Header.Set("Accept", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code, "the error is rendered as JSON when it can not be rendered as protobuf")
+	assert.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+
+	app.Post("/typed", "/:id", typedHandler())
+	r = httptest.NewRequest(http.MethodPost, "/typed/0", strings.NewReader(`{"name":"routey"}`))
+	r.Header.Set("Accept", "application/yaml")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "error: not found\n", w.Body.String())
+}
@@ -0,0 +1,695 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// WebSocket message types, from RFC 6455
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// WebSocket close codes, from RFC 6455
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// The largest message that can be read when WebSocketConfig.MaxMessageSize is not set
+const DefaultMaxMessageSize = 1 << 20
+
+// A message, or a decompressed message, is larger than the MaxMessageSize
+var errMessageTooBig = errors.New("websocket: message too big")
+
+// The tail of a deflated message, removed when writing and added back when reading
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+var flateWriterPool = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+// Handles an upgraded WebSocket connection
+type WebSocketFunc func(ws *WSConn)
+
+// Configure a WebSocket upgrade
+//
+//   - Subprotocols: the subprotocols supported by the server, in order of preference
+//
+//   - Compression: negotiate permessage-deflate if the client offers it
+//
+//   - MaxMessageSize: the largest message in bytes that can be read, after it is decompressed, defaults to DefaultMaxMessageSize
+//
+//   - CheckOrigin: returns true if the Origin is allowed, defaults to allowing the same host
+type WebSocketConfig struct {
+	Subprotocols   []string
+	Compression    bool
+	MaxMessageSize int64
+	CheckOrigin    func(r *http.Request) bool
+}
+
+// A WebSocket connection
+//
+//   - conn: the hijacked connection
+//
+//   - rw: buffered reader and writer of the connection
+//
+//   - context: the Context of the upgraded request
+//
+//   - deflate: has permessage-deflate been negotiated?
+//
+//   - subprotocol: the negotiated subprotocol
+type WSConn struct {
+	conn    net.Conn
+	rw      *bufio.ReadWriter
+	context *Context
+
+	deflate        bool
+	subprotocol    string
+	maxMessageSize int64
+
+	wmu       sync.Mutex
+	closeOnce sync.Once
+	closeSent bool
+}
+
+// A close frame received from the client, or sent when the connection failed
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Get the message of the CloseError
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed %d %s", e.Code, e.Text)
+}
+
+// A single frame read from the connection
+type wsFrame struct {
+	fin     bool
+	rsv1    bool
+	opcode  byte
+	payload []byte
+}
+
+// Add a WebSocket route, the handler is called with the upgraded connection.
+// The connection is closed once the handler returns.
+func (a *App) WebSocket(path string, params string, f WebSocketFunc, cfg ...WebSocketConfig) {
+	a.Get(path, params, func(c *Context) {
+		ws, err := c.Upgrade(cfg...)
+		if err != nil {
+			return
+		}
+		defer ws.Close(CloseNormalClosure, "")
+
+		f(ws)
+	})
+}
+
+// Upgrade the request to a WebSocket connection, responding with an error if the handshake is invalid.
+func (c *Context) Upgrade(cfg ...WebSocketConfig) (*WSConn, error) {
+	o := WebSocketConfig{}
+	if len(cfg) > 0 {
+		o = cfg[0]
+	}
+	if o.MaxMessageSize <= 0 {
+		o.MaxMessageSize = DefaultMaxMessageSize
+	}
+
+	r := c.request
+	switch {
+	case r.Method != http.MethodGet:
+		return nil, c.upgradeError(http.StatusMethodNotAllowed, "websocket: method must be GET")
+	case !headerContains(r.Header, "Connection", "upgrade"):
+		return nil, c.upgradeError(http.StatusBadRequest, "websocket: connection header must contain upgrade")
+	case !headerContains(r.Header, "Upgrade", "websocket"):
+		return nil, c.upgradeError(http.StatusBadRequest, "websocket: upgrade header must be websocket")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		c.Header("Sec-WebSocket-Version", "13")
+		return nil, c.upgradeError(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+
+	k := r.Header.Get("Sec-WebSocket-Key")
+	d, err := base64.StdEncoding.DecodeString(k)
+	if err != nil || len(d) != 16 {
+		return nil, c.upgradeError(http.StatusBadRequest, "websocket: invalid Sec-WebSocket-Key")
+	}
+
+	check := o.CheckOrigin
+	if check == nil {
+		check = sameOrigin
+	}
+	if !check(r) {
+		return nil, c.upgradeError(http.StatusForbidden, "websocket: origin not allowed")
+	}
+
+	ws := &WSConn{
+		context:        c,
+		maxMessageSize: o.MaxMessageSize,
+		subprotocol:    selectSubprotocol(r, o.Subprotocols),
+		deflate:        o.Compression && offersDeflate(r),
+	}
+
+	conn, rw, err := http.NewResponseController(c.writer).Hijack()
+	if err != nil {
+		return nil, c.upgradeError(http.StatusInternalServerError, "websocket: "+err.Error())
+	}
+	ws.conn = conn
+	ws.rw = rw
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	b.WriteString("Upgrade: websocket\r\n")
+	b.WriteString("Connection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(k) + "\r\n")
+	if ws.subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + ws.subprotocol + "\r\n")
+	}
+	if ws.deflate {
+		b.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	b.WriteString("\r\n")
+
+	_, err = rw.WriteString(b.String())
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.status = http.StatusSwitchingProtocols
+	if c.app != nil {
+		c.app.trackWebSocket(ws, true)
+	}
+
+	return ws, nil
+}
+
+// Respond to a failed upgrade
+func (c *Context) upgradeError(s int, m string) error {
+	err := errors.New(m)
+	c.HandleError(s, err)
+	return err
+}
+
+// Get the Context of the upgraded request
+func (ws *WSConn) Context() *Context {
+	return ws.context
+}
+
+// Get the negotiated subprotocol, empty if there is none
+func (ws *WSConn) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// Read the next data message, replying to pings and handling close frames.
+// A *CloseError is returned once the connection has been closed.
+func (ws *WSConn) ReadMessage() (int, []byte, error) {
+	t := 0
+	compressed := false
+	var m []byte
+
+	for {
+		f, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if f.opcode&0x08 != 0 {
+			err = ws.handleControl(f)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		switch f.opcode {
+		case continuationFrame:
+			if t == 0 || f.rsv1 {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case TextMessage, BinaryMessage:
+			if t != 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "expected continuation frame")
+			}
+			if f.rsv1 && !ws.deflate {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected rsv1 bit")
+			}
+			t = int(f.opcode)
+			compressed = f.rsv1
+		default:
+			return 0, nil, ws.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		m = append(m, f.payload...)
+		if int64(len(m)) > ws.maxMessageSize {
+			return 0, nil, ws.fail(CloseMessageTooBig, "message too big")
+		}
+
+		if !f.fin {
+			continue
+		}
+
+		if compressed {
+			m, err = ws.inflate(m)
+			if errors.Is(err, errMessageTooBig) {
+				return 0, nil, ws.fail(CloseMessageTooBig, "message too big")
+			}
+			if err != nil {
+				return 0, nil, ws.fail(CloseInvalidFramePayloadData, "invalid compressed message")
+			}
+		}
+		if t == TextMessage && !utf8.Valid(m) {
+			return 0, nil, ws.fail(CloseInvalidFramePayloadData, "invalid utf-8 text")
+		}
+
+		return t, m, nil
+	}
+}
+
+// Read the next message and decode it as JSON into a
+func (ws *WSConn) ReadJSON(a any) error {
+	_, m, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(m, a)
+}
+
+// Write a data message, compressing it when permessage-deflate was negotiated
+func (ws *WSConn) WriteMessage(t int, m []byte) error {
+	if t != TextMessage && t != BinaryMessage {
+		return errors.New("websocket: data messages must be text or binary")
+	}
+
+	if !ws.deflate {
+		return ws.writeFrame(byte(t), m, false)
+	}
+
+	d, err := ws.compress(m)
+	if err != nil {
+		return err
+	}
+
+	return ws.writeFrame(byte(t), d, true)
+}
+
+// Encode a as JSON and write it as a text message
+func (ws *WSConn) WriteJSON(a any) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	return ws.WriteMessage(TextMessage, b)
+}
+
+// Send a ping, the client will reply with a pong
+func (ws *WSConn) Ping(d []byte) error {
+	if len(d) > 125 {
+		return errors.New("websocket: control frame too large")
+	}
+
+	return ws.writeFrame(PingMessage, d, false)
+}
+
+// Send a close frame with a code and reason, then close the connection
+func (ws *WSConn) Close(code int, reason string) error {
+	var err error
+
+	ws.closeOnce.Do(func() {
+		ws.sendClose(code, reason)
+		err = ws.conn.Close()
+
+		if ws.context != nil && ws.context.app != nil {
+			ws.context.app.trackWebSocket(ws, false)
+		}
+	})
+
+	return err
+}
+
+// Handle a control frame
+func (ws *WSConn) handleControl(f wsFrame) error {
+	if !f.fin || len(f.payload) > 125 || f.rsv1 {
+		return ws.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	switch f.opcode {
+	case PingMessage:
+		return ws.writeFrame(PongMessage, f.payload, false)
+	case PongMessage:
+		return nil
+	case CloseMessage:
+		e := &CloseError{Code: CloseNoStatusReceived}
+
+		switch {
+		case len(f.payload) == 1:
+			return ws.fail(CloseProtocolError, "invalid close frame")
+		case len(f.payload) >= 2:
+			e.Code = int(binary.BigEndian.Uint16(f.payload))
+			e.Text = string(f.payload[2:])
+
+			if !validCloseCode(e.Code) || !utf8.ValidString(e.Text) {
+				return ws.fail(CloseProtocolError, "invalid close frame")
+			}
+		}
+
+		code := e.Code
+		if code == CloseNoStatusReceived {
+			code = CloseNormalClosure
+		}
+		ws.Close(code, "")
+
+		return e
+	default:
+		return ws.fail(CloseProtocolError, "unknown control opcode")
+	}
+}
+
+// Fail the connection with a close code
+func (ws *WSConn) fail(code int, reason string) error {
+	ws.Close(code, reason)
+	return &CloseError{Code: code, Text: reason}
+}
+
+// Send a close frame, only once
+func (ws *WSConn) sendClose(code int, reason string) {
+	ws.wmu.Lock()
+	sent := ws.closeSent
+	ws.closeSent = true
+	ws.wmu.Unlock()
+
+	if sent {
+		return
+	}
+
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	p = append(p, reason...)
+	if len(p) > 125 {
+		p = p[:125]
+	}
+
+	ws.writeFrame(CloseMessage, p, false)
+}
+
+// Read a single frame, unmasking the payload
+func (ws *WSConn) readFrame() (wsFrame, error) {
+	h := make([]byte, 2)
+	_, err := io.ReadFull(ws.rw, h)
+	if err != nil {
+		return wsFrame{}, ws.readError(err)
+	}
+
+	f := wsFrame{
+		fin:    h[0]&0x80 != 0,
+		rsv1:   h[0]&0x40 != 0,
+		opcode: h[0] & 0x0f,
+	}
+	if h[0]&0x30 != 0 {
+		return f, ws.fail(CloseProtocolError, "unexpected rsv bits")
+	}
+	if h[1]&0x80 == 0 {
+		return f, ws.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		b := make([]byte, 2)
+		_, err = io.ReadFull(ws.rw, b)
+		n = uint64(binary.BigEndian.Uint16(b))
+	case 127:
+		b := make([]byte, 8)
+		_, err = io.ReadFull(ws.rw, b)
+		n = binary.BigEndian.Uint64(b)
+	}
+	if err != nil {
+		return f, ws.readError(err)
+	}
+	if n > uint64(ws.maxMessageSize) {
+		return f, ws.fail(CloseMessageTooBig, "message too big")
+	}
+
+	mask := make([]byte, 4)
+	_, err = io.ReadFull(ws.rw, mask)
+	if err != nil {
+		return f, ws.readError(err)
+	}
+
+	f.payload = make([]byte, n)
+	_, err = io.ReadFull(ws.rw, f.payload)
+	if err != nil {
+		return f, ws.readError(err)
+	}
+
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+
+	return f, nil
+}
+
+// Close the connection after a read error, the client went away without a close frame
+func (ws *WSConn) readError(err error) error {
+	ws.closeOnce.Do(func() {
+		ws.conn.Close()
+
+		if ws.context != nil && ws.context.app != nil {
+			ws.context.app.trackWebSocket(ws, false)
+		}
+	})
+
+	return errors.Join(&CloseError{Code: CloseAbnormalClosure}, err)
+}
+
+// Write a single unmasked frame
+func (ws *WSConn) writeFrame(op byte, p []byte, rsv1 bool) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+
+	h := make([]byte, 2, 10)
+	h[0] = 0x80 | op
+	if rsv1 {
+		h[0] |= 0x40
+	}
+
+	switch n := len(p); {
+	case n <= 125:
+		h[1] = byte(n)
+	case n <= 0xffff:
+		h[1] = 126
+		h = binary.BigEndian.AppendUint16(h, uint16(n))
+	default:
+		h[1] = 127
+		h = binary.BigEndian.AppendUint64(h, uint64(n))
+	}
+
+	_, err := ws.rw.Write(h)
+	if err != nil {
+		return err
+	}
+
+	_, err = ws.rw.Write(p)
+	if err != nil {
+		return err
+	}
+
+	return ws.rw.Flush()
+}
+
+// Compress a message for permessage-deflate
+func (ws *WSConn) compress(m []byte) ([]byte, error) {
+	b := &bytes.Buffer{}
+	w := flateWriterPool.Get().(*flate.Writer)
+	defer flateWriterPool.Put(w)
+	w.Reset(b)
+
+	_, err := w.Write(m)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(b.Bytes(), deflateTail), nil
+}
+
+// Decompress a permessage-deflate message
+func (ws *WSConn) inflate(m []byte) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(
+		bytes.NewReader(m),
+		bytes.NewReader(deflateTail),
+		bytes.NewReader([]byte{0x01, 0x00, 0x00, 0xff, 0xff}),
+	))
+	defer r.Close()
+
+	b, err := io.ReadAll(io.LimitReader(r, ws.maxMessageSize+1))
+	if err == nil && int64(len(b)) > ws.maxMessageSize {
+		return nil, errMessageTooBig
+	}
+	return b, err
+}
+
+// Add or remove an open WebSocket, so that it can be closed on shutdown
+func (a *App) trackWebSocket(ws *WSConn, open bool) {
+	a.wsMu.Lock()
+	defer a.wsMu.Unlock()
+
+	if !open {
+		delete(a.websockets, ws)
+		return
+	}
+
+	if a.websockets == nil {
+		a.websockets = make(map[*WSConn]struct{})
+	}
+	a.websockets[ws] = struct{}{}
+}
+
+// Close all of the open WebSockets as the server is going away
+func (a *App) closeWebSockets() {
+	a.wsMu.Lock()
+	ws := make([]*WSConn, 0, len(a.websockets))
+	for w := range a.websockets {
+		ws = append(ws, w)
+	}
+	a.wsMu.Unlock()
+
+	for _, w := range ws {
+		w.Close(CloseGoingAway, "server shutting down")
+	}
+}
+
+// Compute the Sec-WebSocket-Accept of a key
+func acceptKey(k string) string {
+	h := sha1.New()
+	h.Write([]byte(k + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Does the header contain the token, in a comma separated list?
+func headerContains(h http.Header, k string, t string) bool {
+	for _, v := range h.Values(k) {
+		for _, p := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(p), t) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Is the Origin of the request the same as its host? Requests without an Origin are allowed
+func sameOrigin(r *http.Request) bool {
+	o := r.Header.Get("Origin")
+	if o == "" {
+		return true
+	}
+
+	u, err := url.Parse(o)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Choose the first subprotocol offered by the client that the server supports
+func selectSubprotocol(r *http.Request, s []string) string {
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			p = strings.TrimSpace(p)
+			for _, e := range s {
+				if p == e {
+					return p
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// Does the client offer permessage-deflate, with parameters that can be used?
+func offersDeflate(r *http.Request) bool {
+	for _, v := range r.Header.Values("Sec-WebSocket-Extensions") {
+		for _, e := range strings.Split(v, ",") {
+			ps := strings.Split(e, ";")
+			if strings.TrimSpace(ps[0]) != "permessage-deflate" {
+				continue
+			}
+
+			ok := true
+			for _, p := range ps[1:] {
+				k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+				switch k {
+				case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+				case "server_max_window_bits":
+					ok = ok && strings.Trim(v, `"`) == "15"
+				default:
+					ok = false
+				}
+			}
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Can the close code be sent by a client?
+func validCloseCode(c int) bool {
+	switch {
+	case c >= 1000 && c <= 1003, c >= 1007 && c <= 1011:
+		return true
+	case c >= 3000 && c <= 4999:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,349 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func wsTestServer(cfg WebSocketConfig) (*App, *httptest.Server) {
+	app := New()
+	app.WebSocket("/ws", "/:room", func(ws *WSConn) {
+		room, _ := ws.Context().Param("room")
+		if room == "json" {
+			var m M
+			err := ws.ReadJSON(&m)
+			if err != nil {
+				return
+			}
+			ws.WriteJSON(m)
+			return
+		}
+
+		for {
+			t, m, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.WriteMessage(t, m)
+		}
+	}, cfg)
+
+	return app, httptest.NewServer(app)
+}
+
+func wsDial(t *testing.T, s *httptest.Server, path string, h http.Header) (*wsTestClient, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(s.URL, "http://"))
+	assert.NoError(t, err)
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r, _ := http.NewRequest(http.MethodGet, s.URL+path, nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, v := range h {
+		r.Header[k] = v
+	}
+	err = r.Write(conn)
+	assert.NoError(t, err)
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, r)
+	assert.NoError(t, err)
+
+	return &wsTestClient{conn: conn, br: br}, res
+}
+
+func (c *wsTestClient) write(fin bool, rsv1 bool, op byte, p []byte, masked bool) {
+	h := []byte{op, 0}
+	if fin {
+		h[0] |= 0x80
+	}
+	if rsv1 {
+		h[0] |= 0x40
+	}
+
+	switch {
+	case len(p) <= 125:
+		h[1] = byte(len(p))
+	case len(p) <= 0xffff:
+		h[1] = 126
+		h = binary.BigEndian.AppendUint16(h, uint16(len(p)))
+	default:
+		h[1] = 127
+		h = binary.BigEndian.AppendUint64(h, uint64(len(p)))
+	}
+
+	b := append([]byte{}, p...)
+	if masked {
+		h[1] |= 0x80
+		mask := make([]byte, 4)
+		rand.Read(mask)
+		h = append(h, mask...)
+		for i := range b {
+			b[i] ^= mask[i%4]
+		}
+	}
+
+	c.conn.Write(append(h, b...))
+}
+
+func (c *wsTestClient) read() (byte, bool, []byte, error) {
+	h := make([]byte, 2)
+	_, err := io.ReadFull(c.br, h)
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	n := int(h[1] & 0x7f)
+	switch n {
+	case 126:
+		b := make([]byte, 2)
+		io.ReadFull(c.br, b)
+		n = int(binary.BigEndian.Uint16(b))
+	case 127:
+		b := make([]byte, 8)
+		io.ReadFull(c.br, b)
+		n = int(binary.BigEndian.Uint64(b))
+	}
+
+	p := make([]byte, n)
+	_, err = io.ReadFull(c.br, p)
+	return h[0] & 0x0f, h[0]&0x40 != 0, p, err
+}
+
+func (c *wsTestClient) readClose(t *testing.T) int {
+	op, _, p, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(CloseMessage), op)
+	assert.GreaterOrEqual(t, len(p), 2)
+
+	return int(binary.BigEndian.Uint16(p))
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	c, res := wsDial(t, s, "/ws/echo", nil)
+	defer c.conn.Close()
+
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+	assert.Empty(t, res.Header.Get("Sec-WebSocket-Extensions"))
+}
+
+func TestWebSocketHandshakeInvalid(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	_, res := wsDial(t, s, "/ws/echo", http.Header{"Sec-Websocket-Key": {"short"}})
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	_, res = wsDial(t, s, "/ws/echo", http.Header{"Sec-Websocket-Version": {"8"}})
+	assert.Equal(t, http.StatusUpgradeRequired, res.StatusCode)
+	assert.Equal(t, "13", res.Header.Get("Sec-WebSocket-Version"))
+
+	_, res = wsDial(t, s, "/ws/echo", http.Header{"Origin": {"http://evil.example"}})
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res, err := http.Get(s.URL + "/ws/echo")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	c, _ := wsDial(t, s, "/ws/echo", nil)
+	defer c.conn.Close()
+
+	c.write(true, false, TextMessage, []byte("hello"), true)
+	op, _, p, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(TextMessage), op)
+	assert.Equal(t, "hello", string(p))
+
+	large := bytes.Repeat([]byte{1}, 70000)
+	c.write(true, false, BinaryMessage, large, true)
+	op, _, p, err = c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(BinaryMessage), op)
+	assert.Equal(t, large, p)
+}
+
+func TestWebSocketJSON(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	c, _ := wsDial(t, s, "/ws/json", nil)
+	defer c.conn.Close()
+
+	c.write(true, false, TextMessage, []byte(`{"name":"routey"}`), true)
+	op, _, p, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(TextMessage), op)
+	assert.JSONEq(t, `{"name":"routey"}`, string(p))
+	assert.Equal(t, CloseNormalClosure, c.readClose(t))
+}
+
+func TestWebSocketFragmented(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	c, _ := wsDial(t, s, "/ws/echo", nil)
+	defer c.conn.Close()
+
+	c.write(false, false, TextMessage, []byte("hel"), true)
+	c.write(true, false, PingMessage, []byte("ping"), true)
+	c.write(false, false, continuationFrame, []byte("lo "), true)
+	c.write(true, false, continuationFrame, []byte("world"), true)
+
+	op, _, p, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(PongMessage), op)
+	assert.Equal(t, "ping", string(p))
+
+	op, _, p, err = c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(TextMessage), op)
+	assert.Equal(t, "hello world", string(p))
+}
+
+func TestWebSocketClose(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	c, _ := wsDial(t, s, "/ws/echo", nil)
+	defer c.conn.Close()
+
+	p := binary.BigEndian.AppendUint16(nil, CloseGoingAway)
+	c.write(true, false, CloseMessage, append(p, "bye"...), true)
+	assert.Equal(t, CloseGoingAway, c.readClose(t))
+
+	_, _, _, err := c.read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{MaxMessageSize: 16})
+	defer s.Close()
+
+	cases := map[int]func(c *wsTestClient){
+		CloseProtocolError: func(c *wsTestClient) {
+			c.write(true, false, TextMessage, []byte("unmasked"), false)
+		},
+		CloseInvalidFramePayloadData: func(c *wsTestClient) {
+			c.write(true, false, TextMessage, []byte{0xff, 0xfe}, true)
+		},
+		CloseMessageTooBig: func(c *wsTestClient) {
+			c.write(true, false, BinaryMessage, make([]byte, 17), true)
+		},
+	}
+
+	for code, f := range cases {
+		c, _ := wsDial(t, s, "/ws/echo", nil)
+		f(c)
+		assert.Equal(t, code, c.readClose(t))
+		c.conn.Close()
+	}
+
+	c, _ := wsDial(t, s, "/ws/echo", nil)
+	defer c.conn.Close()
+	c.write(true, false, continuationFrame, []byte("a"), true)
+	assert.Equal(t, CloseProtocolError, c.readClose(t))
+}
+
+func TestWebSocketMaxMessageSize(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{Compression: true})
+	defer s.Close()
+
+	c, _ := wsDial(t, s, "/ws/echo", nil)
+	h := binary.BigEndian.AppendUint64([]byte{0x80 | BinaryMessage, 0x80 | 127}, 1<<62)
+	c.conn.Write(append(h, 0, 0, 0, 0))
+	assert.Equal(t, CloseMessageTooBig, c.readClose(t))
+	c.conn.Close()
+
+	c, _ = wsDial(t, s, "/ws/echo", http.Header{
+		"Sec-Websocket-Extensions": {"permessage-deflate"},
+	})
+	defer c.conn.Close()
+
+	b := &bytes.Buffer{}
+	w, _ := flate.NewWriter(b, flate.BestCompression)
+	w.Write(make([]byte, DefaultMaxMessageSize+1))
+	w.Flush()
+	assert.Less(t, b.Len(), DefaultMaxMessageSize)
+	c.write(true, true, BinaryMessage, bytes.TrimSuffix(b.Bytes(), deflateTail), true)
+	assert.Equal(t, CloseMessageTooBig, c.readClose(t))
+}
+
+func TestWebSocketCompression(t *testing.T) {
+	_, s := wsTestServer(WebSocketConfig{Compression: true})
+	defer s.Close()
+
+	c, res := wsDial(t, s, "/ws/echo", http.Header{
+		"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"},
+	})
+	defer c.conn.Close()
+	assert.Contains(t, res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	m := []byte(strings.Repeat("routey ", 20))
+	b := &bytes.Buffer{}
+	w, _ := flate.NewWriter(b, flate.BestCompression)
+	w.Write(m)
+	w.Flush()
+	c.write(true, true, TextMessage, bytes.TrimSuffix(b.Bytes(), deflateTail), true)
+
+	op, rsv1, p, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(TextMessage), op)
+	assert.True(t, rsv1)
+	assert.Less(t, len(p), len(m))
+
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(p), bytes.NewReader(deflateTail), bytes.NewReader([]byte{0x01, 0x00, 0x00, 0xff, 0xff})))
+	d, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, m, d)
+}
+
+func TestWebSocketShutdown(t *testing.T) {
+	app, s := wsTestServer(WebSocketConfig{})
+	defer s.Close()
+
+	c, _ := wsDial(t, s, "/ws/echo", nil)
+	defer c.conn.Close()
+
+	c.write(true, false, TextMessage, []byte("hello"), true)
+	c.read()
+
+	app.closeWebSockets()
+	assert.Equal(t, CloseGoingAway, c.readClose(t))
+	assert.Empty(t, app.websockets)
+}
+
+func TestAcceptKey(t *testing.T) {
+	k := make([]byte, 16)
+	rand.Read(k)
+	assert.Len(t, acceptKey(base64.StdEncoding.EncodeToString(k)), 28)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}